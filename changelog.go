@@ -0,0 +1,168 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"fybrik.io/json-schema-generator/pkg/schemas"
+)
+
+// memoryDocument is a generated document kept in memory
+type memoryDocument struct {
+	bytes.Buffer
+}
+
+func (*memoryDocument) Close() error {
+	return nil
+}
+
+// writeChangelog writes the changelog of the schemas of the roots from the sources at the old git ref
+// to the current sources. Relative roots are resolved against the same directory in both sources, and
+// absolute roots are rebased onto the old sources, which requires them to be within the repository.
+func writeChangelog(w io.Writer, generator schemas.Generator, options schemas.LoadOptions, oldRef string, rootPaths ...string) error {
+	// Note: the documents are already reported on by the generation of the current sources
+	generator.Summary, generator.Warnings = nil, nil
+	newDocuments, err := generateInMemory(generator, options, rootPaths...)
+	if err != nil {
+		return err
+	}
+	checkout, err := checkoutRef(options.Dir, oldRef)
+	if err != nil {
+		return err
+	}
+	defer checkout.remove()
+	oldRootPaths := make([]string, len(rootPaths))
+	for i, rootPath := range rootPaths {
+		if oldRootPaths[i], err = checkout.rebase(rootPath); err != nil {
+			return err
+		}
+	}
+	oldOptions := options
+	oldOptions.Dir = checkout.dir
+	oldDocuments, err := generateInMemory(generator, oldOptions, oldRootPaths...)
+	if err != nil {
+		return fmt.Errorf("sources at %s: %w", oldRef, err)
+	}
+	return schemas.WriteChangelog(w, oldDocuments, newDocuments)
+}
+
+// generateInMemory generates the documents of the roots, keyed by document name
+func generateInMemory(generator schemas.Generator, options schemas.LoadOptions, rootPaths ...string) (map[string][]byte, error) {
+	var generators genall.Generators
+	generators = addGenerator(generators, &generator)
+	runtime, err := schemas.ForRoots(generators, options, rootPaths...)
+	if err != nil {
+		return nil, err
+	}
+	documents := make(map[string]*memoryDocument)
+	err = generator.GenerateTo(&runtime.GenerationContext, func(name string) (io.WriteCloser, error) {
+		documents[name] = &memoryDocument{}
+		return documents[name], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if loader.PrintErrors(runtime.Roots, packages.TypeError) {
+		return nil, errors.New("generator failed with errors")
+	}
+	data := make(map[string][]byte, len(documents))
+	for name, document := range documents {
+		data[name] = document.Bytes()
+	}
+	return data, nil
+}
+
+// checkout is a temporary git worktree with the sources at an old ref
+type checkout struct {
+	// topLevel is the top-level directory of the repository
+	topLevel string
+	// worktree is the top-level directory of the worktree
+	worktree string
+	// dir is the directory of the worktree that corresponds to the directory of the current sources
+	dir string
+}
+
+// checkoutRef checks out the given git ref of the repository of a directory into a temporary worktree
+func checkoutRef(dir, ref string) (*checkout, error) {
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Note: git resolves the symbolic links of the top-level directory
+	if absDir, err = filepath.EvalSymlinks(absDir); err != nil {
+		return nil, err
+	}
+	topLevel, err := git(absDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	relDir, err := filepath.Rel(topLevel, absDir)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := os.MkdirTemp("", "json-schema-generator-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(topLevel, "worktree", "add", "--detach", "--quiet", worktree, ref); err != nil {
+		_ = os.RemoveAll(worktree)
+		return nil, err
+	}
+	return &checkout{topLevel: topLevel, worktree: worktree, dir: filepath.Join(worktree, relDir)}, nil
+}
+
+// rebase returns the path of a root in the worktree. Relative roots are relative to the directory of
+// the worktree, like they are relative to the directory of the current sources, while absolute roots
+// are moved from the repository to the worktree.
+func (c *checkout) rebase(rootPath string) (string, error) {
+	if !filepath.IsAbs(rootPath) {
+		return rootPath, nil
+	}
+	// the directory of a go-style pattern, such as /path/to/pkgs/..., may be a symbolic link
+	dir, pattern := rootPath, ""
+	if strings.HasSuffix(rootPath, "/...") {
+		dir, pattern = strings.TrimSuffix(rootPath, "/..."), "/..."
+	}
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		rootPath = realDir + pattern
+	}
+	relPath, err := filepath.Rel(c.topLevel, rootPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("root %s is outside of the repository %s, so it has no sources at the old ref", rootPath, c.topLevel)
+	}
+	return filepath.Join(c.worktree, relPath), nil
+}
+
+// remove removes the worktree
+func (c *checkout) remove() {
+	if _, err := git(c.topLevel, "worktree", "remove", "--force", c.worktree); err != nil {
+		fmt.Fprintf(os.Stderr, "Error removing worktree: %s\n", err)
+	}
+	_ = os.RemoveAll(c.worktree)
+}
+
+// git runs a git command in the given directory, and returns its trimmed output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
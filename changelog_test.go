@@ -0,0 +1,100 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fybrik.io/json-schema-generator/pkg/schemas"
+)
+
+// commitFixture copies the files of a fixture package into a package of the repository, replacing
+// the old strings of the files with the new ones, and commits them
+func commitFixture(t *testing.T, repo, fixture, pkg string, replacer *strings.Replacer) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(fixture, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, pkg), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, pkg, filepath.Base(file)), []byte(replacer.Replace(string(data))), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "update " + pkg},
+	} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	if _, err := git(repo, "init", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module example.com/changelog\n\ngo 1.19\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	commitFixture(t, repo, "testPkgs/schemapkg", "schemapkg", strings.NewReplacer())
+	commitFixture(t, repo, "testPkgs/schemapkg", "schemapkg", strings.NewReplacer(
+		"type UnusedType struct {", "type UnusedType struct {\n\tUnusedF2 int `json:\"unusedf2,omitempty\"`\n"))
+
+	expected := "# Changelog\n\n## schemapkg.json\n\n- Added optional field `UnusedType.unusedf2`\n"
+	// Note: absolute roots are rebased onto the sources at the old ref, rather than compared with themselves
+	for _, root := range []string{"./schemapkg", filepath.Join(repo, "schemapkg"), filepath.Join(repo, "...")} {
+		var changelog bytes.Buffer
+		if err := writeChangelog(&changelog, schemas.Generator{}, schemas.LoadOptions{Dir: repo}, "HEAD~1", root); err != nil {
+			t.Fatalf("%s: error %v\n", root, err)
+		}
+		if changelog.String() != expected {
+			t.Errorf("%s: unexpected changelog:\n%s", root, changelog.String())
+		}
+	}
+}
+
+func TestRebaseRoot(t *testing.T) {
+	c := &checkout{topLevel: "/repo", worktree: "/worktree", dir: "/worktree/dir"}
+	for _, tt := range []struct {
+		root     string
+		expected string
+	}{
+		{"./pkg", "./pkg"},
+		{"pkg/...", "pkg/..."},
+		{"/repo/pkg", "/worktree/pkg"},
+		{"/repo/pkgs/...", "/worktree/pkgs/..."},
+		{"/repo", "/worktree"},
+		{"/other/pkg", ""},
+		{"/repository/pkg", ""},
+	} {
+		rebased, err := c.rebase(tt.root)
+		if tt.expected == "" {
+			if err == nil || !strings.Contains(err.Error(), "outside of the repository") {
+				t.Errorf("%s: expected an error for a root outside of the repository, got %s %v", tt.root, rebased, err)
+			}
+			continue
+		}
+		if err != nil || rebased != tt.expected {
+			t.Errorf("%s: expected %s, got %s %v", tt.root, tt.expected, rebased, err)
+		}
+	}
+}
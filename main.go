@@ -5,12 +5,15 @@ package main
 
 import (
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 
 	"fybrik.io/json-schema-generator/pkg/schemas"
@@ -20,21 +23,90 @@ import (
 var version string
 
 const (
-	rootsOption  = "roots"
-	outputOption = "output"
+	rootsOption               = "roots"
+	outputOption              = "output"
+	archiveOption             = "archive"
+	refAliasesOption          = "ref-aliases"
+	coalesceCyclesOption      = "coalesce-cycles"
+	externalBaseURIOption     = "external-base-uri"
+	idBaseOption              = "id-base"
+	buildTagsOption           = "build-tags"
+	cwdOption                 = "cwd"
+	allowDangerousTypesOption = "allow-dangerous-types"
+	pruneUnreferencedOption   = "prune-unreferenced"
+	recursiveRefsOption       = "recursive-refs"
+	extensionOption           = "extension"
+	formatOption              = "format"
+	headerCommentOption       = "header-comment"
+	summaryOption             = "summary"
+	refEncodingOption         = "ref-encoding"
+	exampleTagOption          = "example-tag"
+	validateExamplesOption    = "validate-examples"
+	validateOption            = "validate"
+	openAPIOption             = "openapi"
+	stripK8sExtensionsOption  = "strip-k8s-extensions"
+	redactOption              = "redact"
+	hoistAnonymousOption      = "hoist-anonymous"
+	descriptionsOption        = "descriptions"
+	langOption                = "lang"
+	includeTestsOption        = "include-tests"
+	trailingNewlineOption     = "trailing-newline"
+	failFastOption            = "fail-fast"
+	enumDescriptionsOption    = "enum-descriptions"
+	inferEnumsOption          = "infer-enums"
+	rootRefOnlyOption         = "root-ref-only"
+	typeOverridesOption       = "type-overrides"
+	cleanRefsOption           = "clean-refs"
+	closedStyleOption         = "closed-style"
+	bigNumbersOption          = "big-numbers"
+	jsonNumbersOption         = "json-numbers"
+	nullableCollectionsOption = "nullable-collections"
+	sqlNullScalarsOption      = "sql-null-scalars"
+	maxDepthOption            = "max-depth"
+	concurrencyOption         = "concurrency"
+	harvestDeprecationsOption = "harvest-deprecations"
+	emitManifestOption        = "emit-manifest"
+	groupByObjectOption       = "group-by-object"
+	schemaDialectOption       = "schema-dialect"
+	nullableOmitEmptyOption   = "nullable-omitempty"
+	nullablePointersOption    = "nullable-pointers"
+	markerPrefixOption        = "marker-prefix"
+	formatAssertionOption     = "format-assertion"
+	definitionsRootOption     = "definitions-root"
+	changelogOption           = "changelog"
+	emptyStructOption         = "empty-struct"
+	propagateRequiredOption   = "propagate-required"
+	propertyCaseOption        = "property-case"
+	assumeIntWidthOption      = "assume-int-width"
 )
 
-var (
-	roots     []string
-	outputDir string
-)
+// rootOptions are the options of the root cli command, which its flags set
+type rootOptions struct {
+	generator           schemas.Generator
+	load                schemas.LoadOptions
+	roots               []string
+	allowDangerousTypes bool
+	inferEnums          bool
+	extension           string
+	summary             bool
+	changelogRef        string
+}
 
 func addGenerator(generators genall.Generators, generator genall.Generator) genall.Generators {
 	return append(generators, &generator)
 }
 
+// resolvePath resolves a relative path against the --cwd directory, if set
+func (options *rootOptions) resolvePath(path string) string {
+	if options.load.Dir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(options.load.Dir, path)
+}
+
 // RootCmd defines the root cli command
 func RootCmd() *cobra.Command {
+	options := &rootOptions{}
 	cmd := &cobra.Command{
 		Use:           "json-schema-generator",
 		Short:         "Generate JSON schemas from Go structures",
@@ -42,22 +114,191 @@ func RootCmd() *cobra.Command {
 		SilenceUsage:  true,
 		Version:       strings.TrimSpace(version),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var generators genall.Generators
-			generators = addGenerator(generators, &schemas.Generator{OutputDir: outputDir})
-			runtime, err := generators.ForRoots(roots...)
+			return options.run(cmd)
+		},
+	}
+	addInputFlags(cmd.Flags(), options)
+	addTypeFlags(cmd.Flags(), options)
+	addSchemaFlags(cmd.Flags(), &options.generator)
+	addOutputFlags(cmd.Flags(), options)
+	_ = cmd.MarkFlagRequired(rootsOption)
+	_ = cmd.MarkFlagRequired(outputOption)
+	cmd.AddCommand(listMarkersCmd())
+	return cmd
+}
+
+// run generates the documents of the roots, and prints the changelog against the --changelog ref, if set
+func (options *rootOptions) run(cmd *cobra.Command) error {
+	generator := options.generator
+	generator.OutputDir = options.resolvePath(generator.OutputDir)
+	generator.RefAliases = options.resolvePath(generator.RefAliases)
+	generator.Descriptions = options.resolvePath(generator.Descriptions)
+	generator.TypeOverrides = options.resolvePath(generator.TypeOverrides)
+	generator.AllowDangerousTypes = &options.allowDangerousTypes
+	generator.InferEnums = &options.inferEnums
+	if cmd.Flags().Changed(extensionOption) {
+		generator.Extension = &options.extension
+	}
+	if options.summary {
+		generator.Summary = cmd.ErrOrStderr()
+	}
+	generator.Warnings = cmd.ErrOrStderr()
+	var generators genall.Generators
+	generators = addGenerator(generators, &generator)
+	runtime, err := schemas.ForRoots(generators, options.load, options.roots...)
+	if err != nil {
+		return err
+	}
+	if runtime.Run() {
+		return errors.New("generator failed with errors")
+	}
+	if options.changelogRef != "" {
+		return writeChangelog(cmd.OutOrStdout(), generator, options.load, options.changelogRef, options.roots...)
+	}
+	return nil
+}
+
+// addInputFlags adds the flags of the packages to load and of the files that the generation reads
+func addInputFlags(flags *pflag.FlagSet, options *rootOptions) {
+	generator := &options.generator
+	flags.StringSliceVarP(&options.roots, rootsOption, "r", []string{}, "Paths and go-style path patterns to use as package roots")
+	flags.StringVar(&options.load.Dir, cwdOption, "",
+		"Directory to resolve relative roots and paths against, instead of the current working directory")
+	flags.StringSliceVar(&options.load.BuildTags, buildTagsOption, []string{}, "Build tags to consider when loading the package roots")
+	flags.BoolVar(&options.load.Tests, includeTestsOption, false, "Include the types declared in the _test.go files of the package roots")
+	flags.IntVar(&generator.Concurrency, concurrencyOption, 1, "Maximum number of root packages to load in parallel")
+	flags.BoolVar(&generator.FailFast, failFastOption, false, "Abort at the first error instead of reporting all errors")
+	flags.StringVar(&generator.RefAliases, refAliasesOption, "",
+		"JSON file mapping old <pkgPath>.<TypeName> names of moved types to their current names")
+	flags.StringVar(&generator.TypeOverrides, typeOverridesOption, "",
+		"JSON file mapping <pkgPath>.<TypeName> names of types to the schemas to generate for them, e.g., for other UUID libraries")
+	flags.StringVar(&generator.Descriptions, descriptionsOption, "",
+		"JSON file mapping languages to the localized descriptions of types and fields by qualified name")
+	flags.StringVar(&generator.Lang, langOption, "", "Language of the descriptions to use from the descriptions file")
+	flags.StringVar(&generator.MarkerPrefix, markerPrefixOption, "fybrik:validation",
+		"Prefix of the markers of the generator, e.g., acme:validation for +acme:validation:schema")
+	flags.StringVar(&options.changelogRef, changelogOption, "",
+		"Git ref of older sources to print a markdown changelog of the schemas against, resolving relative roots in both sources")
+}
+
+// addTypeFlags adds the flags of the schemas generated for Go types
+func addTypeFlags(flags *pflag.FlagSet, options *rootOptions) {
+	generator := &options.generator
+	flags.StringVar(&generator.ExampleTag, exampleTagOption, "", "Name of a struct tag holding the examples of fields")
+	flags.BoolVar(&generator.NullableCollections, nullableCollectionsOption, false,
+		"Permit null items of slices of pointers and null values of maps of pointers")
+	flags.BoolVar(&generator.NullablePointers, nullablePointersOption, false,
+		"Permit null for pointer fields, such as *time.Time fields, which are marshaled as null when nil")
+	flags.BoolVar(&generator.NullableOmitEmpty, nullableOmitEmptyOption, false,
+		"Permit null for omitempty slice and map fields, while other slice and map fields require an array or an object")
+	flags.BoolVar(&generator.SQLNullScalars, sqlNullScalarsOption, false,
+		"Generate sql.Null* fields as nullable scalars, for types whose custom marshaling writes the value or null")
+	flags.BoolVar(&generator.BigNumbers, bigNumbersOption, false,
+		"Generate big.Float fields as numbers instead of strings")
+	flags.BoolVar(&generator.JSONNumbers, jsonNumbersOption, false,
+		"Generate json.Number fields as numbers or numeric strings instead of strings")
+	flags.BoolVar(&generator.EnumDescriptions, enumDescriptionsOption, false,
+		"Derive the enum of types from their declared constants, with the doc comments of the constants as x-enum-descriptions")
+	flags.BoolVar(&options.inferEnums, inferEnumsOption, true,
+		"Set the enum of string and integer types to the values of their exported constants")
+	flags.StringVar(&generator.EmptyStruct, emptyStructOption, "open",
+		"Handling of structs without fields: open (any object) or closed (only the empty object)")
+	flags.IntVar(&generator.AssumeIntWidth, assumeIntWidthOption, 64,
+		"Width in bits, 32 or 64, assumed for int and uint, which sets their int32 or int64 format")
+	flags.IntVar(&generator.MaxDepth, maxDepthOption, 0,
+		"Maximum number of nested types to expand, reporting an error for deeper types (0 for no limit)")
+	flags.BoolVar(&generator.HarvestDeprecations, harvestDeprecationsOption, false,
+		"Set the deprecated keyword of types and fields whose doc comments have a paragraph starting with \"Deprecated: \"")
+}
+
+// addSchemaFlags adds the flags of the processing of the generated schemas
+func addSchemaFlags(flags *pflag.FlagSet, generator *schemas.Generator) {
+	flags.BoolVar(&generator.CoalesceCycles, coalesceCyclesOption, false,
+		"Merge the definitions of documents that reference each other in a cycle into a single document")
+	flags.BoolVar(&generator.PruneUnreferenced, pruneUnreferencedOption, false,
+		"Remove definitions that are not referenced from an object or from a type in a root package without the schema marker")
+	flags.BoolVar(&generator.RecursiveRefs, recursiveRefsOption, false,
+		"Reference self-recursive types with $recursiveRef and generate draft 2019-09 schemas")
+	flags.StringVar(&generator.RefEncoding, refEncodingOption, "pointer",
+		"Encoding of the definition names in reference fragments: pointer (JSON pointer escapes) or percent (also percent-encoded)")
+	flags.BoolVar(&generator.ValidateExamples, validateExamplesOption, false,
+		"Fail if an example, such as the example of a field, violates its schema")
+	flags.BoolVar(&generator.Validate, validateOption, false,
+		"Fail if an object document, along with the documents it references, doesn't compile as a schema")
+	flags.BoolVar(&generator.StripK8sExtensions, stripK8sExtensionsOption, false,
+		"Remove all x-kubernetes-* extensions from the generated documents")
+	flags.BoolVar(&generator.HoistAnonymous, hoistAnonymousOption, false,
+		"Reference a single definition from the structurally identical anonymous structs of a document")
+	flags.BoolVar(&generator.Redact, redactOption, false,
+		"Remove the data values, i.e., defaults and examples, from the generated documents")
+	flags.StringVar(&generator.ClosedStyle, closedStyleOption, "",
+		"Forbid undeclared struct properties: additional (additionalProperties: false) "+
+			"or unevaluated (also unevaluatedProperties: false for structs with embedded bases)")
+	flags.BoolVar(&generator.PropagateRequired, propagateRequiredOption, false,
+		"Add the required properties of the embedded bases of structs to the required properties of the structs")
+	flags.StringVar(&generator.PropertyCase, propertyCaseOption, "",
+		"Rename the properties, along with their required entries, to a case: camel (e.g., maxRetries) or snake (e.g., max_retries)")
+	flags.BoolVar(&generator.CleanRefs, cleanRefsOption, false, "Wrap each $ref that has sibling keywords, such as a description, in an allOf")
+	flags.BoolVar(&generator.FormatAssertion, formatAssertionOption, false,
+		"Generate draft 2020-12 schemas whose meta-schema, under the external base URI, asserts formats")
+	flags.StringVar(&generator.SchemaDialect, schemaDialectOption, "",
+		"Dialect of the $schema of the documents: draft-07, 2019-09 or 2020-12, which also moves the definitions under $defs. "+
+			"Unset, the documents are draft-07, unless other options require a later draft")
+	flags.StringVar(&generator.ExternalBaseURI, externalBaseURIOption, "",
+		"Base URI to set the $id of external.json under, making references to it absolute")
+	flags.StringVar(&generator.IDBase, idBaseOption, "",
+		"Base URI to set the $id of each document under, e.g., https://schemas.example.com/v1/, "+
+			"making references between documents absolute")
+}
+
+// addOutputFlags adds the flags of the layout and the serialization of the generated documents
+func addOutputFlags(flags *pflag.FlagSet, options *rootOptions) {
+	generator := &options.generator
+	flags.StringVarP(&generator.OutputDir, outputOption, "o", "", "Directory to save JSON schema artifact to")
+	flags.StringVar(&generator.Archive, archiveOption, "",
+		"Name of a .zip or .tar.gz file in the output directory to write all JSON schemas to")
+	flags.BoolVar(&options.allowDangerousTypes, allowDangerousTypesOption, false, "Allow float32 and float64 types")
+	flags.StringVar(&options.extension, extensionOption, "",
+		"Suffix of the generated document names (default .json, or .yaml in the yaml format)")
+	flags.StringVar(&generator.Format, formatOption, "json", "Serialization of the generated documents: json or yaml")
+	flags.StringVar(&generator.HeaderComment, headerCommentOption, "",
+		"Comment to start each YAML document with, e.g., \"AUTO-GENERATED, DO NOT EDIT\", with each line prefixed with #")
+	flags.BoolVar(&generator.TrailingNewline, trailingNewlineOption, false, "End each generated document with a newline")
+	flags.BoolVar(&generator.OpenAPI, openAPIOption, false,
+		"Generate a single openapi document with all types under components.schemas, instead of a document per package")
+	flags.BoolVar(&generator.RootRefOnly, rootRefOnlyOption, false,
+		"Add a root document that only references the documents of the types with the object marker")
+	flags.StringVar(&generator.DefinitionsRoot, definitionsRootOption, "definitions",
+		"Key of the definitions of each document, e.g., $defs, to which the fragments of references point")
+	flags.BoolVar(&generator.EmitManifest, emitManifestOption, false,
+		"Add a manifest.json document listing the generated documents with their ids, titles and definitions, and the object documents")
+	flags.BoolVar(&generator.GroupByObject, groupByObjectOption, false,
+		"Move each object document into a directory of its own, along with the referenced definitions of the documents it references")
+	flags.BoolVar(&options.summary, summaryOption, false, "Print a summary of the generated documents to stderr")
+}
+
+// listMarkersCmd defines the cli command that prints the supported markers as JSON
+func listMarkersCmd() *cobra.Command {
+	var generator schemas.Generator
+	cmd := &cobra.Command{
+		Use:   "list-markers",
+		Short: "Print the supported markers and their help as JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			markersHelp, err := generator.MarkersHelp()
 			if err != nil {
 				return err
 			}
-			if runtime.Run() {
-				return errors.New("generator failed with errors")
+			bytes, err := json.MarshalIndent(markersHelp, "", "  ")
+			if err != nil {
+				return err
 			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(bytes))
 			return nil
 		},
 	}
-	cmd.Flags().StringSliceVarP(&roots, rootsOption, "r", []string{}, "Paths and go-style path patterns to use as package roots")
-	_ = cmd.MarkFlagRequired(rootsOption)
-	cmd.Flags().StringVarP(&outputDir, outputOption, "o", "", "Directory to save JSON schema artifact to")
-	_ = cmd.MarkFlagRequired(outputOption)
+	cmd.Flags().StringVar(&generator.MarkerPrefix, markerPrefixOption, "fybrik:validation",
+		"Prefix of the markers of the generator to list, e.g., acme:validation for +acme:validation:schema")
 	return cmd
 }
 
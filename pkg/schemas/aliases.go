@@ -0,0 +1,99 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// loadRefAliases reads a JSON object that maps old `<pkgPath>.<TypeName>` names to current ones
+func loadRefAliases(path string) (map[string]string, error) {
+	bytes, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]string)
+	if err := json.Unmarshal(bytes, &aliases); err != nil {
+		return nil, fmt.Errorf("invalid ref aliases file %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// splitTypeName splits a `<pkgPath>.<TypeName>` name into the package path and the type name
+func splitTypeName(name string) (pkgPath, typeName string, err error) {
+	index := strings.LastIndex(name, ".")
+	if index <= strings.LastIndex(name, "/") || index == len(name)-1 {
+		return Empty, Empty, fmt.Errorf("invalid type name %q, expected <pkgPath>.<TypeName>", name)
+	}
+	return name[:index], name[index+1:], nil
+}
+
+// typeIdentByName finds a type with a generated schema by its package path and name
+func (context *GeneratorContext) typeIdentByName(pkgPath, typeName string) (crd.TypeIdent, bool) {
+	for typeIdent := range context.parser.Schemata {
+		if typeIdent.Name == typeName && loader.NonVendorPath(typeIdent.Package.PkgPath) == pkgPath {
+			return typeIdent, true
+		}
+	}
+	return crd.TypeIdent{}, false
+}
+
+// hasDefinition returns whether a document has a definition of the given name
+func hasDefinition(document *apiext.JSONSchemaProps, definitionName string) bool {
+	_, exists := document.Definitions[definitionName]
+	return exists
+}
+
+// addRefAliases adds a definition to external.json for each old type name in the ref aliases file.
+// The definition references the current definition of the type, so that refs to the old name still resolve.
+func (context *GeneratorContext) addRefAliases(documents map[string]*apiext.JSONSchemaProps, path string) error {
+	aliases, err := loadRefAliases(path)
+	if err != nil {
+		return err
+	}
+	for oldName, currentName := range aliases {
+		oldPkgPath, oldTypeName, err := splitTypeName(oldName)
+		if err != nil {
+			return err
+		}
+		pkgPath, typeName, err := splitTypeName(currentName)
+		if err != nil {
+			return err
+		}
+		typeIdent, found := context.typeIdentByName(pkgPath, typeName)
+		if !found {
+			return fmt.Errorf("ref alias %s: no schema was generated for %s", oldName, currentName)
+		}
+
+		externalDocument := context.externalDocumentName()
+		document, exists := documents[externalDocument]
+		if !exists {
+			document, err = context.newDocument(externalDocument)
+			if err != nil {
+				return err
+			}
+			documents[externalDocument] = document
+		}
+		targetDocument := context.documentNameForType(typeIdent)
+		definitionName := context.definitionNameFor(targetDocument, typeIdent)
+		// Note: the definition of a generated schema may have been pruned, which would leave the alias dangling
+		if target, exists := documents[targetDocument]; !exists || !hasDefinition(target, definitionName) {
+			return fmt.Errorf("ref alias %s: document %s has no definition of %s", oldName, targetDocument, currentName)
+		}
+		link := context.definitionFragment(definitionName)
+		if targetDocument != externalDocument {
+			link = context.documentURI(targetDocument) + link
+		}
+		document.Definitions[qualifiedName(oldPkgPath, oldTypeName)] = apiext.JSONSchemaProps{Ref: &link}
+	}
+	return nil
+}
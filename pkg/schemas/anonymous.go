@@ -0,0 +1,171 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"go/ast"
+	"strconv"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// anonymousKeyword marks the schemas of anonymous structs with the synthetic name of their definition,
+// should they be hoisted.  It is removed from all the documents after the hoisting.
+const anonymousKeyword = "x-fybrik-anonymous"
+
+// NodeMarkers returns the markers of the given node of a package, such as a field of an anonymous struct
+func (context *GeneratorContext) NodeMarkers(pkg *loader.Package, node ast.Node) markers.MarkerValues {
+	nodeMarkers, err := context.parser.Collector.MarkersInPackage(pkg)
+	if err != nil {
+		pkg.AddError(err)
+		return nil
+	}
+	values := nodeMarkers[node]
+	context.normalizeMarkers(values)
+	return values
+}
+
+// anonymousInfo returns the information of the fields of an anonymous struct, like the information
+// that the markers collector returns for the struct types of type declarations
+func anonymousInfo(ctx *schemaContext, structType *ast.StructType) *markers.TypeInfo {
+	name := ctx.anonymousName
+	if name == Empty {
+		name = ctx.info.Name
+	}
+	info := &markers.TypeInfo{Name: name}
+	for _, field := range structType.Fields.List {
+		fieldInfo := markers.FieldInfo{
+			Doc:      fieldDoc(field),
+			Tag:      loader.ParseAstTag(field.Tag),
+			Markers:  ctx.schemaRequester.NodeMarkers(ctx.pkg, field),
+			RawField: field,
+		}
+		if field.Names == nil {
+			info.Fields = append(info.Fields, fieldInfo)
+		}
+		for _, fieldName := range field.Names {
+			fieldInfo.Name = fieldName.Name
+			info.Fields = append(info.Fields, fieldInfo)
+		}
+	}
+	return info
+}
+
+// fieldDoc returns the doc comment of a field, without its markers
+func fieldDoc(field *ast.Field) string {
+	if field.Doc == nil {
+		return Empty
+	}
+	var lines []string
+	for _, line := range strings.Split(field.Doc.Text(), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "+") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// hoistAnonymousStructs replaces the structurally identical schemas of anonymous structs that occur more than
+// once in a document with references to a single definition, named after the containing type and field that sort first
+func hoistAnonymousStructs(documents map[string]*apiext.JSONSchemaProps) error {
+	for documentName, document := range documents {
+		// Note: the documents share the schemas of types, so the hoisting changes a copy
+		document = document.DeepCopy()
+		documents[documentName] = document
+
+		counts := map[string]int{}
+		names := map[string]string{}
+		var err error
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			key, name, isAnonymous, keyErr := anonymousStructKey(subschema)
+			if keyErr != nil {
+				err = keyErr
+			}
+			if !isAnonymous {
+				return
+			}
+			counts[key]++
+			if existing, exists := names[key]; !exists || name < existing {
+				names[key] = name
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		hoisted := map[string]string{}
+		definitions := apiext.JSONSchemaDefinitions{}
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			key, _, isAnonymous, _ := anonymousStructKey(subschema)
+			if !isAnonymous || counts[key] < 2 {
+				return
+			}
+			definitionName, exists := hoisted[key]
+			if !exists {
+				definitionName = uniqueDefinitionName(names[key], document.Definitions, definitions)
+				hoisted[key] = definitionName
+				definition := *subschema.DeepCopy()
+				definition.Description = Empty
+				definitions[definitionName] = definition
+			}
+			ref := "#/" + defaultDefinitionsRoot + "/" + definitionName
+			*subschema = apiext.JSONSchemaProps{Ref: &ref, Description: subschema.Description}
+		})
+		if len(definitions) == 0 {
+			continue
+		}
+		if document.Definitions == nil {
+			document.Definitions = make(apiext.JSONSchemaDefinitions)
+		}
+		for name := range definitions {
+			document.Definitions[name] = definitions[name]
+		}
+	}
+	return nil
+}
+
+// anonymousStructKey returns the structure of the schema of an anonymous struct, without its description
+// and synthetic names, and its synthetic name
+func anonymousStructKey(schema *apiext.JSONSchemaProps) (key, name string, isAnonymous bool, err error) {
+	if isAnonymous, err = getKeyword(schema, anonymousKeyword, &name); err != nil || !isAnonymous {
+		return Empty, Empty, false, err
+	}
+	structure := schema.DeepCopy()
+	structure.Description = Empty
+	stripAnonymousNames(structure)
+	data, err := json.Marshal(structure)
+	if err != nil {
+		return Empty, Empty, false, err
+	}
+	return string(data), name, true, nil
+}
+
+// uniqueDefinitionName returns the given name, with a number appended if it is already used by a definition
+func uniqueDefinitionName(name string, definitions ...apiext.JSONSchemaDefinitions) string {
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate += strconv.Itoa(i)
+		}
+		used := false
+		for _, existing := range definitions {
+			_, exists := existing[candidate]
+			used = used || exists
+		}
+		if !used {
+			return candidate
+		}
+	}
+}
+
+// stripAnonymousNames removes the synthetic names of the anonymous structs of the schema and of its subschemas
+func stripAnonymousNames(schema *apiext.JSONSchemaProps) {
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		deleteKeyword(subschema, anonymousKeyword)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const archiveEntryMode = 0o644
+
+// outputArchive writes the documents as entries of a single archive in OutputDir.
+// The archive format is selected by its extension: .zip, .tar.gz or .tgz
+func (g Generator) outputArchive(documents map[string][]byte) (err error) {
+	var write func(io.Writer, []string, map[string][]byte) error
+	switch {
+	case strings.HasSuffix(g.Archive, ".zip"):
+		write = g.writeZip
+	case strings.HasSuffix(g.Archive, ".tar.gz"), strings.HasSuffix(g.Archive, ".tgz"):
+		write = g.writeTarGz
+	default:
+		return fmt.Errorf("unsupported archive %s, expected a .zip, .tar.gz or .tgz file", g.Archive)
+	}
+
+	f, err := os.Create(filepath.Clean(filepath.Join(g.OutputDir, g.Archive)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// sort the entries for a reproducible archive
+	docNames := make([]string, 0, len(documents))
+	for docName := range documents {
+		docNames = append(docNames, docName)
+	}
+	sort.Strings(docNames)
+	return write(f, docNames, documents)
+}
+
+func (g Generator) writeZip(w io.Writer, docNames []string, documents map[string][]byte) error {
+	zw := zip.NewWriter(w)
+	for _, docName := range docNames {
+		bytes := documents[docName]
+		entry, err := zw.Create(docName)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(bytes); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (g Generator) writeTarGz(w io.Writer, docNames []string, documents map[string][]byte) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, docName := range docNames {
+		bytes := documents[docName]
+		header := &tar.Header{
+			Name:    docName,
+			Mode:    archiveEntryMode,
+			Size:    int64(len(bytes)),
+			ModTime: time.Unix(0, 0),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(bytes); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
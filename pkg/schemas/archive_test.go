@@ -0,0 +1,123 @@
+package schemas
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func sampleDocuments() map[string]*apiext.JSONSchemaProps {
+	return map[string]*apiext.JSONSchemaProps{
+		"a.json": {Title: "a.json", Type: "object"},
+		"b.json": {Title: "b.json", Type: "string"},
+	}
+}
+
+// sampleData marshals the sample documents, as the generator outputs them
+func sampleData(t *testing.T) map[string][]byte {
+	t.Helper()
+	data := make(map[string][]byte)
+	for name, doc := range sampleDocuments() {
+		bytes, err := Generator{}.marshalDocument(doc)
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		data[name] = bytes
+	}
+	return data
+}
+
+func checkEntry(t *testing.T, documents map[string]*apiext.JSONSchemaProps, name string, content []byte) {
+	t.Helper()
+	doc, exists := documents[name]
+	if !exists {
+		t.Errorf("unexpected archive entry %s", name)
+		return
+	}
+	expected, err := Generator{}.marshalDocument(doc)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if string(content) != string(expected) {
+		t.Errorf("entry %s: expected %s, got %s", name, expected, content)
+	}
+	delete(documents, name)
+}
+
+func TestZipArchive(t *testing.T) {
+	g := Generator{OutputDir: t.TempDir(), Archive: "schemas.zip"}
+	if err := g.output(sampleData(t)); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	r, err := zip.OpenReader(filepath.Join(g.OutputDir, g.Archive))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	defer r.Close()
+
+	documents := sampleDocuments()
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		checkEntry(t, documents, f.Name, content)
+	}
+	for name := range documents {
+		t.Errorf("missing archive entry %s", name)
+	}
+}
+
+func TestTarGzArchive(t *testing.T) {
+	g := Generator{OutputDir: t.TempDir(), Archive: "schemas.tar.gz"}
+	if err := g.output(sampleData(t)); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	f, err := os.Open(filepath.Join(g.OutputDir, g.Archive))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	tr := tar.NewReader(gr)
+
+	documents := sampleDocuments()
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		checkEntry(t, documents, header.Name, content)
+	}
+	for name := range documents {
+		t.Errorf("missing archive entry %s", name)
+	}
+}
+
+func TestUnsupportedArchive(t *testing.T) {
+	g := Generator{OutputDir: t.TempDir(), Archive: "schemas.rar"}
+	if err := g.output(sampleData(t)); err == nil {
+		t.Error("expected an error for an unsupported archive format")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// annotationKeywords are the keywords that don't constrain values, whose changes are left out of changelogs
+var annotationKeywords = map[string]bool{
+	"title":       true,
+	"description": true,
+	"example":     true,
+}
+
+// changelog collects the changes between two versions of the generated documents
+type changelog struct {
+	lines []string
+}
+
+// WriteChangelog writes a markdown changelog of the types, fields and constraints that were added,
+// removed or modified from the old documents to the new documents, both keyed by document name
+func WriteChangelog(w io.Writer, oldDocuments, newDocuments map[string][]byte) error {
+	names := map[string]bool{}
+	for name := range oldDocuments {
+		names[name] = true
+	}
+	for name := range newDocuments {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changes := 0
+	fmt.Fprintln(w, "# Changelog")
+	for _, name := range sorted {
+		oldData, hasOld := oldDocuments[name]
+		newData, hasNew := newDocuments[name]
+		if !hasOld {
+			fmt.Fprintf(w, "\n## %s\n\n- Added document\n", name)
+			changes++
+			continue
+		}
+		if !hasNew {
+			fmt.Fprintf(w, "\n## %s\n\n- Removed document\n", name)
+			changes++
+			continue
+		}
+		oldDocument, newDocument := &apiext.JSONSchemaProps{}, &apiext.JSONSchemaProps{}
+		if err := json.Unmarshal(oldData, oldDocument); err != nil {
+			return fmt.Errorf("old document %s: %w", name, err)
+		}
+		if err := json.Unmarshal(newData, newDocument); err != nil {
+			return fmt.Errorf("new document %s: %w", name, err)
+		}
+		log := &changelog{}
+		if err := log.compareDocuments(oldDocument, newDocument); err != nil {
+			return fmt.Errorf("document %s: %w", name, err)
+		}
+		if len(log.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", name)
+		for _, line := range log.lines {
+			fmt.Fprintf(w, "- %s\n", line)
+		}
+		changes += len(log.lines)
+	}
+	if changes == 0 {
+		fmt.Fprintln(w, "\nNo changes")
+	}
+	return nil
+}
+
+func (c *changelog) add(format string, args ...interface{}) {
+	c.lines = append(c.lines, fmt.Sprintf(format, args...))
+}
+
+// compareDocuments compares the definitions of two versions of a document, and their roots,
+// which are the object schemas of object documents
+func (c *changelog) compareDocuments(oldDocument, newDocument *apiext.JSONSchemaProps) error {
+	oldDefinitions, newDefinitions := oldDocument.Definitions, newDocument.Definitions
+	oldDocument.Definitions, newDocument.Definitions = nil, nil
+	if err := c.compareSchemas("(root)", oldDocument, newDocument); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(oldDefinitions, newDefinitions) {
+		oldSchema, hasOld := oldDefinitions[name]
+		newSchema, hasNew := newDefinitions[name]
+		switch {
+		case !hasOld:
+			c.add("Added type `%s`", name)
+		case !hasNew:
+			c.add("Removed type `%s`", name)
+		default:
+			if err := c.compareSchemas(name, &oldSchema, &newSchema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareSchemas compares the fields and the constraints of two versions of the schema at the given path
+func (c *changelog) compareSchemas(path string, oldSchema, newSchema *apiext.JSONSchemaProps) error {
+	if err := c.compareKeywords(path, oldSchema, newSchema); err != nil {
+		return err
+	}
+	if err := c.compareProperties(path, oldSchema, newSchema); err != nil {
+		return err
+	}
+	if oldSchema.Items != nil && newSchema.Items != nil && oldSchema.Items.Schema != nil && newSchema.Items.Schema != nil {
+		if err := c.compareSchemas(path+"[]", oldSchema.Items.Schema, newSchema.Items.Schema); err != nil {
+			return err
+		}
+	}
+	if oldSchema.AdditionalProperties != nil && newSchema.AdditionalProperties != nil &&
+		oldSchema.AdditionalProperties.Schema != nil && newSchema.AdditionalProperties.Schema != nil {
+		return c.compareSchemas(path+"{}", oldSchema.AdditionalProperties.Schema, newSchema.AdditionalProperties.Schema)
+	}
+	return nil
+}
+
+// compareProperties adds the changes of the properties of a schema, and of their schemas, to the changelog
+func (c *changelog) compareProperties(path string, oldSchema, newSchema *apiext.JSONSchemaProps) error {
+	for _, name := range sortedKeys(oldSchema.Properties, newSchema.Properties) {
+		fieldPath := path + "." + name
+		if path == "(root)" {
+			fieldPath = name
+		}
+		oldField, hasOld := oldSchema.Properties[name]
+		newField, hasNew := newSchema.Properties[name]
+		switch {
+		case !hasOld:
+			c.add("Added %s field `%s`", requirement(newSchema, name), fieldPath)
+			continue
+		case !hasNew:
+			c.add("Removed field `%s`", fieldPath)
+			continue
+		}
+		wasRequired, isRequired := indexOf(name, oldSchema.Required) != -1, indexOf(name, newSchema.Required) != -1
+		if wasRequired != isRequired {
+			c.add("Made field `%s` %s", fieldPath, requirement(newSchema, name))
+		}
+		if err := c.compareSchemas(fieldPath, &oldField, &newField); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareKeywords compares the constraints of two versions of a schema, apart from the fields and the
+// subschemas of items and values, which are compared on their own
+func (c *changelog) compareKeywords(path string, oldSchema, newSchema *apiext.JSONSchemaProps) error {
+	oldKeywords, err := constraintKeywords(oldSchema)
+	if err != nil {
+		return err
+	}
+	newKeywords, err := constraintKeywords(newSchema)
+	if err != nil {
+		return err
+	}
+	for _, keyword := range sortedKeys(oldKeywords, newKeywords) {
+		oldValue, hasOld := oldKeywords[keyword]
+		newValue, hasNew := newKeywords[keyword]
+		switch {
+		case !hasOld:
+			c.add("Added `%s: %s` to `%s`", keyword, string(newValue), path)
+		case !hasNew:
+			c.add("Removed `%s: %s` from `%s`", keyword, string(oldValue), path)
+		case !reflect.DeepEqual(compactJSON(oldValue), compactJSON(newValue)):
+			c.add("Changed `%s` of `%s` from `%s` to `%s`", keyword, path, string(oldValue), string(newValue))
+		}
+	}
+	return nil
+}
+
+// constraintKeywords returns the keywords of a schema by name, without annotations and without the
+// fields and the subschemas of items and values, unless they are booleans
+func constraintKeywords(schema *apiext.JSONSchemaProps) (map[string]json.RawMessage, error) {
+	shallow := *schema
+	shallow.Properties, shallow.Required = nil, nil
+	if shallow.Items != nil && shallow.Items.Schema != nil {
+		shallow.Items = nil
+	}
+	if shallow.AdditionalProperties != nil && shallow.AdditionalProperties.Schema != nil {
+		shallow.AdditionalProperties = nil
+	}
+	data, err := json.Marshal(&shallow)
+	if err != nil {
+		return nil, err
+	}
+	keywords := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, err
+	}
+	for keyword := range annotationKeywords {
+		delete(keywords, keyword)
+	}
+	return keywords, nil
+}
+
+// compactJSON decodes a JSON value for comparison, ignoring the formatting
+func compactJSON(data json.RawMessage) interface{} {
+	var value interface{}
+	_ = json.Unmarshal(data, &value)
+	return value
+}
+
+// requirement describes whether a property of a schema is required
+func requirement(schema *apiext.JSONSchemaProps, name string) string {
+	if indexOf(name, schema.Required) != -1 {
+		return "required"
+	}
+	return "optional"
+}
+
+// sortedKeys returns the keys of two maps, sorted
+func sortedKeys[V any](first, second map[string]V) []string {
+	keys := make([]string, 0, len(first)+len(second))
+	for key := range first {
+		keys = append(keys, key)
+	}
+	for key := range second {
+		if _, exists := first[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
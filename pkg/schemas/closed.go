@@ -0,0 +1,85 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// additionalClosedStyle closes structs with `additionalProperties: false`
+	additionalClosedStyle = "additional"
+	// unevaluatedClosedStyle closes structs with embedded bases with `unevaluatedProperties: false`
+	unevaluatedClosedStyle = "unevaluated"
+
+	// openEmptyStruct generates empty structs as any object
+	openEmptyStruct = "open"
+	// closedEmptyStruct generates empty structs as the empty object
+	closedEmptyStruct = "closed"
+)
+
+// closeStructs forbids the properties of struct schemas that their Go definition doesn't declare.
+//
+// `additionalProperties: false` only sees the properties of its own schema, so it would forbid the
+// properties that a struct inherits from its embedded (allOf) bases. Such structs are left open in the
+// "additional" style, and closed with `unevaluatedProperties: false` of draft 2019-09 in the "unevaluated"
+// style. The embedded bases themselves are left open in both styles, since they can't know the properties
+// of the structs that embed them. It returns whether `unevaluatedProperties` is used.
+func closeStructs(documents map[string]*apiext.JSONSchemaProps, style string) (bool, error) {
+	bases := map[definitionRef]bool{}
+	for name, document := range documents {
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			for _, member := range subschema.AllOf {
+				if member.Ref == nil {
+					continue
+				}
+				if base, isDefinition := parseRef(name, *member.Ref); isDefinition {
+					bases[base] = true
+				}
+			}
+		})
+	}
+
+	unevaluated := false
+	var err error
+	for name, document := range documents {
+		inlined := map[*apiext.JSONSchemaProps]bool{}
+		closeStruct := func(subschema *apiext.JSONSchemaProps) {
+			for i := range subschema.AllOf {
+				inlined[&subschema.AllOf[i]] = true
+			}
+			if inlined[subschema] || !isStructSchema(subschema) {
+				return
+			}
+			switch {
+			case len(subschema.AllOf) == 0:
+				subschema.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{Allows: false}
+			case style == unevaluatedClosedStyle:
+				if keywordErr := setKeyword(subschema, "unevaluatedProperties", false); keywordErr != nil {
+					err = keywordErr
+				}
+				unevaluated = true
+			}
+		}
+		// Note: definitions are closed separately, so that embedded bases can be skipped
+		definitions := document.Definitions
+		document.Definitions = nil
+		walkSchema(document, closeStruct)
+		for definition := range definitions {
+			if bases[definitionRef{document: name, definition: definition}] {
+				continue
+			}
+			schema := definitions[definition]
+			walkSchema(&schema, closeStruct)
+			definitions[definition] = schema
+		}
+		document.Definitions = definitions
+	}
+	return unevaluated, err
+}
+
+// isStructSchema returns whether the schema is the object schema of a struct, rather than of a map
+func isStructSchema(schema *apiext.JSONSchemaProps) bool {
+	return schema.Type == "object" && schema.Properties != nil && schema.AdditionalProperties == nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// coalesceCycles merges the definitions of documents that reference each other, directly or through other
+// documents, into the document of the cycle whose name sorts first, and rewrites the references to the
+// merged documents.  Object documents are left as is, since their roots are schemas of their own.
+func coalesceCycles(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) error {
+	merged := make(map[string]string)
+	for _, component := range stronglyConnectedComponents(documentGraph(documents, objectDocuments)) {
+		if len(component) < 2 {
+			continue
+		}
+		sort.Strings(component)
+		target := component[0]
+		// Note: the documents share the schemas of types, so the merging changes a copy
+		coalesced := documents[target].DeepCopy()
+		for _, documentName := range component[1:] {
+			for name, definition := range documents[documentName].Definitions {
+				if _, exists := coalesced.Definitions[name]; exists {
+					return fmt.Errorf("cannot coalesce document %s into %s: duplicate definition %s", documentName, target, name)
+				}
+				if coalesced.Definitions == nil {
+					coalesced.Definitions = make(apiext.JSONSchemaDefinitions)
+				}
+				coalesced.Definitions[name] = definition
+			}
+			merged[documentName] = target
+			delete(documents, documentName)
+		}
+		documents[target] = coalesced
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	for documentName, document := range documents {
+		document = document.DeepCopy()
+		documents[documentName] = document
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			if subschema.Ref == nil {
+				return
+			}
+			documentPart, fragment, _ := strings.Cut(*subschema.Ref, "#")
+			target, isMerged := merged[path.Base(documentPart)]
+			if documentPart == Empty || !isMerged {
+				return
+			}
+			ref := strings.TrimSuffix(documentPart, path.Base(documentPart)) + target + "#" + fragment
+			subschema.Ref = &ref
+		})
+	}
+	return nil
+}
+
+// documentGraph returns the names of the documents that each document, other than the object documents, references
+func documentGraph(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) map[string][]string {
+	graph := make(map[string][]string)
+	for documentName, document := range documents {
+		if objectDocuments[documentName] {
+			continue
+		}
+		targets := map[string]bool{}
+		for _, ref := range collectRefs(documentName, document) {
+			if ref.document != documentName && !objectDocuments[ref.document] && documents[ref.document] != nil {
+				targets[ref.document] = true
+			}
+		}
+		graph[documentName] = sortedKeys(targets, nil)
+	}
+	return graph
+}
+
+// stronglyConnectedComponents returns the strongly connected components of a directed graph,
+// using Tarjan's algorithm
+func stronglyConnectedComponents(graph map[string][]string) [][]string {
+	index := 0
+	indices := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+
+	var connect func(node string)
+	connect = func(node string) {
+		indices[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, successor := range graph[node] {
+			if _, visited := indices[successor]; !visited {
+				connect(successor)
+				if lowLinks[successor] < lowLinks[node] {
+					lowLinks[node] = lowLinks[successor]
+				}
+			} else if onStack[successor] && indices[successor] < lowLinks[node] {
+				lowLinks[node] = indices[successor]
+			}
+		}
+
+		if lowLinks[node] == indices[node] {
+			var component []string
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				component = append(component, member)
+				if member == node {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range sortedKeys(graph, nil) {
+		if _, visited := indices[node]; !visited {
+			connect(node)
+		}
+	}
+	return components
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// defaultDefinitionsRoot is the key that apiext.JSONSchemaProps marshals the definitions under
+const defaultDefinitionsRoot = "definitions"
+
+// moveDefinitions moves the definitions of the documents under the given key, and updates the
+// references to them. References to documents that weren't generated, such as aliases, are kept.
+func (context *GeneratorContext) moveDefinitions(documents map[string]*apiext.JSONSchemaProps, key string) error {
+	if strings.TrimSpace(key) == Empty {
+		return fmt.Errorf("invalid definitions root %q", key)
+	}
+	token := escapeJSONPointer(key)
+	if context.percentRefs {
+		token = percentEncode(token)
+	}
+	for name, document := range documents {
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			if subschema.Ref == nil {
+				return
+			}
+			ref, isDefinition := parseRef(name, *subschema.Ref)
+			if _, isGenerated := documents[ref.document]; !isDefinition || !isGenerated {
+				return
+			}
+			documentPart, fragment, _ := strings.Cut(*subschema.Ref, "#")
+			moved := documentPart + "#/" + token + strings.TrimPrefix(fragment, "/"+defaultDefinitionsRoot)
+			subschema.Ref = &moved
+		})
+	}
+	for _, document := range documents {
+		definitions := document.Definitions
+		document.Definitions = nil
+		if len(definitions) == 0 {
+			continue
+		}
+		if err := setKeyword(document, key, definitions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
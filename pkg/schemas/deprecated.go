@@ -0,0 +1,41 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"go/ast"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// deprecatedPrefix starts the paragraph of a doc comment that marks a type or field as deprecated, by Go convention
+const deprecatedPrefix = "Deprecated: "
+
+// isDeprecated returns whether any of the doc comments has a paragraph that starts with "Deprecated: "
+func isDeprecated(docs ...*ast.CommentGroup) bool {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+			if strings.HasPrefix(paragraph, deprecatedPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeDocs returns the doc comments of a type, which belong to its declaration unless it is declared in a group
+func typeDocs(info *markers.TypeInfo) []*ast.CommentGroup {
+	var docs []*ast.CommentGroup
+	if info.RawSpec != nil {
+		docs = append(docs, info.RawSpec.Doc)
+	}
+	if info.RawDecl != nil && len(info.RawDecl.Specs) == 1 {
+		docs = append(docs, info.RawDecl.Doc)
+	}
+	return docs
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// loadDescriptions reads the descriptions in the given language from a JSON object that maps
+// languages to objects mapping the qualified names of types, and of their fields as
+// `<qualifiedName>.<fieldName>`, to descriptions
+func loadDescriptions(path, lang string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	translations := make(map[string]map[string]string)
+	if err := json.Unmarshal(data, &translations); err != nil {
+		return nil, fmt.Errorf("invalid descriptions file %s: %w", path, err)
+	}
+	descriptions, exists := translations[lang]
+	if !exists {
+		return nil, fmt.Errorf("no descriptions in language %q in descriptions file %s", lang, path)
+	}
+	return descriptions, nil
+}
+
+// localizeDescriptions overrides the descriptions of the type and of its fields with the loaded ones
+func (context *GeneratorContext) localizeDescriptions(typ crd.TypeIdent, schema *apiext.JSONSchemaProps) {
+	name := qualifiedName(loader.NonVendorPath(typ.Package.PkgPath), typ.Name)
+	if description, exists := context.descriptions[name]; exists {
+		schema.Description = description
+	}
+	for fieldName, property := range schema.Properties {
+		if description, exists := context.descriptions[name+"."+fieldName]; exists {
+			property.Description = description
+			schema.Properties[fieldName] = property
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"errors"
+	"fmt"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// draft07 is the dialect of the `#/definitions/...` references that the documents are generated with
+	draft07 = "http://json-schema.org/draft-07/schema#"
+
+	draft07Dialect     = "draft-07"
+	draft201909Dialect = "2019-09"
+	draft202012Dialect = "2020-12"
+
+	// draft202012DefinitionsRoot is the key of the definitions of draft 2020-12, which replaced `definitions`
+	draft202012DefinitionsRoot = "$defs"
+)
+
+// schemaDialects are the meta-schemas of the dialects of the SchemaDialect option
+var schemaDialects = map[string]string{
+	draft07Dialect:     draft07,
+	draft201909Dialect: draft201909,
+	draft202012Dialect: draft202012,
+}
+
+// checkSchemaDialect returns an error if the dialect is unknown or the options require another dialect.
+// An empty dialect is draft-07, which the options that require a later draft replace, unlike an explicit one.
+func (g Generator) checkSchemaDialect() error {
+	switch g.SchemaDialect {
+	case Empty:
+		return nil
+	case draft07Dialect:
+		switch {
+		case g.RecursiveRefs:
+			return errors.New("recursive refs require draft 2019-09, rather than the draft-07 dialect")
+		case g.ClosedStyle == unevaluatedClosedStyle:
+			return errors.New("the unevaluated closed style requires draft 2019-09, rather than the draft-07 dialect")
+		case g.FormatAssertion:
+			return errors.New("format assertion requires draft 2020-12, rather than the draft-07 dialect")
+		}
+		return nil
+	case draft201909Dialect:
+		if g.FormatAssertion {
+			return errors.New("format assertion requires draft 2020-12, rather than the 2019-09 dialect")
+		}
+		return nil
+	case draft202012Dialect:
+		if g.RecursiveRefs {
+			return errors.New("recursive refs require draft 2019-09, since draft 2020-12 replaced $recursiveRef")
+		}
+		switch g.DefinitionsRoot {
+		case Empty, defaultDefinitionsRoot, draft202012DefinitionsRoot:
+			return nil
+		}
+		return fmt.Errorf("the 2020-12 dialect requires the %s definitions root, rather than %s",
+			draft202012DefinitionsRoot, g.DefinitionsRoot)
+	}
+	return fmt.Errorf("unsupported schema dialect %s, expected %s, %s or %s",
+		g.SchemaDialect, draft07Dialect, draft201909Dialect, draft202012Dialect)
+}
+
+// definitionsRoot returns the key to move the definitions of the documents under, which the 2020-12 dialect sets to `$defs`
+func (g Generator) definitionsRoot() string {
+	if g.SchemaDialect == draft202012Dialect {
+		return draft202012DefinitionsRoot
+	}
+	return g.DefinitionsRoot
+}
+
+// setSchemaDialect sets the `$schema` of the documents whose dialect isn't set by other options, such as the
+// recursive refs that require draft 2019-09.  Draft 2020-12 also supersedes draft 2019-09, whose unevaluated
+// properties it keeps.  An empty dialect is draft-07, the dialect that the documents are generated with.
+func setSchemaDialect(documents map[string]*apiext.JSONSchemaProps, dialect string) {
+	if dialect == Empty {
+		dialect = draft07Dialect
+	}
+	metaSchema := apiext.JSONSchemaURL(schemaDialects[dialect])
+	for _, document := range documents {
+		if document.Schema == Empty || dialect == draft202012Dialect && document.Schema == draft201909 {
+			document.Schema = metaSchema
+		}
+	}
+}
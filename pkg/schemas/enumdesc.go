@@ -0,0 +1,167 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+)
+
+// enumDescriptionsKeyword holds the descriptions of the enum values, in the same order
+const enumDescriptionsKeyword = "x-enum-descriptions"
+
+// addEnumDescriptions sets the enum of a type to the values of the constants of that type that
+// are declared in its package, such as iota enums, along with the doc comments of the constants.
+// Types that already have an enum are left as is.
+func (context *GeneratorContext) addEnumDescriptions(typ crd.TypeIdent, schema *apiext.JSONSchemaProps) error {
+	if len(schema.Enum) > 0 {
+		return nil
+	}
+	enum, descriptions, err := typeConstants(typ, false, nil)
+	if err != nil || len(enum) == 0 {
+		return err
+	}
+	schema.Enum = enum
+	return setKeyword(schema, enumDescriptionsKeyword, descriptions)
+}
+
+// inferEnum sets the enum of a string or integer type to the values of the exported constants of that type
+// that are declared in its package, such as iota enums.  Float types only get an enum if allowDangerousTypes
+// is set, like their schemas.  Types that already have an enum, or have no constants, are left as is, and so
+// are bit flag types, whose constants are declared with shifts, since their values may be combined, and types
+// with constants that JSON can't represent.
+func inferEnum(typ crd.TypeIdent, schema *apiext.JSONSchemaProps, allowDangerousTypes bool) {
+	if len(schema.Enum) > 0 {
+		return
+	}
+	typeName, isTypeName := typ.Package.Types.Scope().Lookup(typ.Name).(*types.TypeName)
+	if !isTypeName {
+		return
+	}
+	basic, isBasic := typeName.Type().Underlying().(*types.Basic)
+	if !isBasic {
+		return
+	}
+	switch info := basic.Info(); {
+	case info&types.IsString != 0, info&types.IsInteger != 0:
+	case info&types.IsFloat != 0 && allowDangerousTypes:
+	default:
+		return
+	}
+	bitFlags := false
+	enum, _, err := typeConstants(typ, true, func(valueExpr ast.Expr) {
+		bitFlags = bitFlags || hasShift(valueExpr)
+	})
+	if err != nil || bitFlags {
+		return
+	}
+	schema.Enum = enum
+}
+
+// typeConstants returns the values of the constants of a type that are declared in its package, in their
+// order of declaration, with their doc comments. Constants declared with iota have their computed values.
+// If visitValue isn't nil, it is called with the value expression of each constant, which is the expression
+// of the previous spec of the declaration for constants that repeat it implicitly.
+func typeConstants(typ crd.TypeIdent, exportedOnly bool, visitValue func(ast.Expr)) (enum []apiext.JSON, descriptions []string, err error) {
+	typeName, isTypeName := typ.Package.Types.Scope().Lookup(typ.Name).(*types.TypeName)
+	if !isTypeName {
+		return nil, nil, nil
+	}
+	for _, file := range typ.Package.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, isGenDecl := decl.(*ast.GenDecl)
+			if !isGenDecl || genDecl.Tok != token.CONST {
+				continue
+			}
+			declEnum, declDescriptions, err := declConstants(typ, typeName, genDecl, exportedOnly, visitValue)
+			if err != nil {
+				return nil, nil, err
+			}
+			enum = append(enum, declEnum...)
+			descriptions = append(descriptions, declDescriptions...)
+		}
+	}
+	return enum, descriptions, nil
+}
+
+// declConstants returns the values of the constants of a type in a constant declaration, with their doc comments
+func declConstants(typ crd.TypeIdent, typeName *types.TypeName, genDecl *ast.GenDecl, exportedOnly bool,
+	visitValue func(ast.Expr)) (enum []apiext.JSON, descriptions []string, err error) {
+	var values []ast.Expr
+	for _, spec := range genDecl.Specs {
+		valueSpec := spec.(*ast.ValueSpec)
+		if len(valueSpec.Values) > 0 {
+			values = valueSpec.Values
+		}
+		for i, name := range valueSpec.Names {
+			constInfo, isConst := typ.Package.TypesInfo.Defs[name].(*types.Const)
+			if !isConst || constInfo.Type() != typeName.Type() || exportedOnly && !constInfo.Exported() {
+				continue
+			}
+			value, err := constantToJSON(constInfo.Val())
+			if err != nil {
+				return nil, nil, fmt.Errorf("constant %s: %w", name.Name, err)
+			}
+			if visitValue != nil && i < len(values) {
+				visitValue(values[i])
+			}
+			enum = append(enum, apiext.JSON{Raw: value})
+			descriptions = append(descriptions, constDoc(genDecl, valueSpec))
+		}
+	}
+	return enum, descriptions, nil
+}
+
+// hasShift returns whether an expression shifts a value, like the constants of bit flags, e.g., `1 << iota`
+func hasShift(expr ast.Expr) bool {
+	shift := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if binary, isBinary := node.(*ast.BinaryExpr); isBinary && (binary.Op == token.SHL || binary.Op == token.SHR) {
+			shift = true
+		}
+		return !shift
+	})
+	return shift
+}
+
+// constDoc returns the doc comment of a constant, falling back to its line comment
+func constDoc(genDecl *ast.GenDecl, valueSpec *ast.ValueSpec) string {
+	doc := valueSpec.Doc
+	if doc == nil && len(genDecl.Specs) == 1 {
+		doc = genDecl.Doc
+	}
+	if doc == nil {
+		doc = valueSpec.Comment
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// constantToJSON marshals the value of a constant
+func constantToJSON(value constant.Value) ([]byte, error) {
+	switch value.Kind() {
+	case constant.Int:
+		if number, isExact := constant.Int64Val(value); isExact {
+			return json.Marshal(number)
+		}
+		if number, isExact := constant.Uint64Val(value); isExact {
+			return json.Marshal(number)
+		}
+	case constant.Float:
+		number, _ := constant.Float64Val(value)
+		return json.Marshal(number)
+	case constant.String:
+		return json.Marshal(constant.StringVal(value))
+	case constant.Bool:
+		return json.Marshal(constant.BoolVal(value))
+	}
+	return nil, fmt.Errorf("unsupported enum value %s", value.ExactString())
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// examplesBaseURI is the base URI that documents without an `$id` are loaded under to validate the examples
+const examplesBaseURI = "file:///schemas/"
+
+// validateExamples validates the example of each schema of the documents against the schema itself,
+// and returns an error that lists the examples that violate their schemas
+func (g Generator) validateExamples(documents map[string]*apiext.JSONSchemaProps) error {
+	loader, uris, err := loadDocuments(documents)
+	if err != nil {
+		return err
+	}
+	names := sortedKeys(documents, nil)
+
+	var failures []string
+	for _, name := range names {
+		var err error
+		walkExamples(documents[name], Empty, "(root)", func(schema *apiext.JSONSchemaProps, pointer, path string) {
+			if err != nil {
+				return
+			}
+			var result *gojsonschema.Result
+			if result, err = validateExample(loader, uris[name]+"#"+pointer, schema.Example); err != nil {
+				err = fmt.Errorf("document %s, %s: %w", name, path, err)
+				return
+			}
+			for _, resultErr := range result.Errors() {
+				message := resultErr.Description()
+				if field := resultErr.Field(); field != "(root)" {
+					message = field + ": " + message
+				}
+				failures = append(failures, fmt.Sprintf("document %s, %s: example %s: %s", name, path, string(schema.Example.Raw), message))
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New("invalid examples:\n" + strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// loadDocuments adds the documents to a gojsonschema loader, so that references between them are resolved,
+// and returns it with the URIs that the documents are loaded under by document name
+func loadDocuments(documents map[string]*apiext.JSONSchemaProps) (*gojsonschema.SchemaLoader, map[string]string, error) {
+	loader := gojsonschema.NewSchemaLoader()
+	loader.AutoDetect = false
+	loader.Draft = gojsonschema.Hybrid
+	uris := make(map[string]string, len(documents))
+	for name, document := range documents {
+		data, err := marshalJSONDocument(document)
+		if err != nil {
+			return nil, nil, err
+		}
+		uri := examplesBaseURI + name
+		if _, err := getKeyword(document, "$id", &uri); err != nil {
+			return nil, nil, err
+		}
+		if err := loader.AddSchema(uri, gojsonschema.NewBytesLoader(data)); err != nil {
+			return nil, nil, fmt.Errorf("document %s: %w", name, err)
+		}
+		uris[name] = uri
+	}
+	return loader, uris, nil
+}
+
+// compileObjectDocuments compiles each object document with gojsonschema, along with the documents that it
+// references, and returns an error that lists the documents that fail to compile, such as documents with
+// references to missing definitions or with malformed patterns
+func compileObjectDocuments(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) error {
+	loader, uris, err := loadDocuments(documents)
+	if err != nil {
+		return err
+	}
+	var failures []string
+	for _, name := range sortedKeys(objectDocuments, nil) {
+		if _, exists := documents[name]; !exists {
+			continue
+		}
+		if _, err := loader.Compile(gojsonschema.NewReferenceLoader(uris[name])); err != nil {
+			failures = append(failures, fmt.Sprintf("document %s: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New("invalid documents:\n" + strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// validateExample validates an example against the schema that the given URI references
+func validateExample(loader *gojsonschema.SchemaLoader, uri string, example *apiext.JSON) (*gojsonschema.Result, error) {
+	schema, err := loader.Compile(gojsonschema.NewReferenceLoader(uri))
+	if err != nil {
+		return nil, err
+	}
+	return schema.Validate(gojsonschema.NewBytesLoader(example.Raw))
+}
+
+// walkExamples calls visit for each schema with an example, with the JSON pointer to the schema and a readable path
+// of the type and field that it describes
+func walkExamples(schema *apiext.JSONSchemaProps, pointer, path string, visit func(*apiext.JSONSchemaProps, string, string)) {
+	if schema.Example != nil {
+		visit(schema, pointer, path)
+	}
+	walkMap := func(schemas map[string]apiext.JSONSchemaProps, keyword string, pathOf func(string) string) {
+		for _, name := range sortedKeys(schemas, nil) {
+			if strings.HasPrefix(name, keywordPrefix) {
+				continue
+			}
+			subschema := schemas[name]
+			walkExamples(&subschema, pointer+"/"+keyword+"/"+percentEncode(escapeJSONPointer(name)), pathOf(name), visit)
+		}
+	}
+	walkSlice := func(schemas []apiext.JSONSchemaProps, keyword string) {
+		for i := range schemas {
+			walkExamples(&schemas[i], pointer+"/"+keyword+"/"+strconv.Itoa(i), path, visit)
+		}
+	}
+
+	walkMap(schema.Definitions, defaultDefinitionsRoot, func(name string) string { return name })
+	walkMap(schema.Properties, "properties", func(name string) string {
+		if path == "(root)" {
+			return name
+		}
+		return path + "." + name
+	})
+	walkMap(schema.PatternProperties, "patternProperties", func(string) string { return path + "{}" })
+	walkSlice(schema.AllOf, "allOf")
+	walkSlice(schema.OneOf, "oneOf")
+	walkSlice(schema.AnyOf, "anyOf")
+	if schema.Items != nil && schema.Items.Schema != nil {
+		walkExamples(schema.Items.Schema, pointer+"/items", path+"[]", visit)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		walkExamples(schema.AdditionalProperties.Schema, pointer+"/additionalProperties", path+"{}", visit)
+	}
+}
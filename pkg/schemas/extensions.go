@@ -0,0 +1,132 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// keywordPrefix marks PatternProperties entries that carry JSON schema keywords which
+// apiext.JSONSchemaProps can't represent (e.g., propertyNames). The value of such a keyword
+// is kept as the default of the entry, and it is lifted to the schema itself by liftKeywords
+// when the document is marshaled.
+const keywordPrefix = "x-fybrik-keyword:"
+
+// setKeyword sets a JSON schema keyword that has no apiext.JSONSchemaProps field
+func setKeyword(props *apiext.JSONSchemaProps, name string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if props.PatternProperties == nil {
+		props.PatternProperties = make(map[string]apiext.JSONSchemaProps)
+	}
+	props.PatternProperties[keywordPrefix+name] = apiext.JSONSchemaProps{Default: &apiext.JSON{Raw: raw}}
+	return nil
+}
+
+// getKeyword reads a keyword set by setKeyword into value.
+// It returns false if the keyword is not set.
+func getKeyword(props *apiext.JSONSchemaProps, name string, value interface{}) (bool, error) {
+	carrier, exists := props.PatternProperties[keywordPrefix+name]
+	if !exists {
+		return false, nil
+	}
+	return true, json.Unmarshal(carrier.Default.Raw, value)
+}
+
+// deleteKeyword removes a keyword set by setKeyword
+func deleteKeyword(props *apiext.JSONSchemaProps, name string) {
+	delete(props.PatternProperties, keywordPrefix+name)
+	if props.PatternProperties != nil && len(props.PatternProperties) == 0 {
+		props.PatternProperties = nil
+	}
+}
+
+// liftKeywords moves the keywords set by setKeyword from patternProperties to the schemas
+// that own them, preserving the order of all other keys
+func liftKeywords(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(keywordPrefix)) {
+		return data, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return data, nil
+	}
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			item, err := liftKeywords(items[i])
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return json.Marshal(items)
+	case '{':
+		object := orderedmap.New[string, json.RawMessage]()
+		if err := json.Unmarshal(data, object); err != nil {
+			return nil, err
+		}
+		for pair := object.Oldest(); pair != nil; pair = pair.Next() {
+			value, err := liftKeywords(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			pair.Value = value
+		}
+		if err := liftPatternProperties(object); err != nil {
+			return nil, err
+		}
+		return json.Marshal(object)
+	default:
+		return data, nil
+	}
+}
+
+func liftPatternProperties(object *orderedmap.OrderedMap[string, json.RawMessage]) error {
+	raw, exists := object.Get("patternProperties")
+	if !exists {
+		return nil
+	}
+	patterns := orderedmap.New[string, json.RawMessage]()
+	if err := json.Unmarshal(raw, patterns); err != nil {
+		return err
+	}
+	keywords := []string{}
+	for pair := patterns.Oldest(); pair != nil; pair = pair.Next() {
+		if strings.HasPrefix(pair.Key, keywordPrefix) {
+			keywords = append(keywords, pair.Key)
+		}
+	}
+	for _, key := range keywords {
+		carrier := struct {
+			Default json.RawMessage `json:"default"`
+		}{}
+		value, _ := patterns.Delete(key)
+		if err := json.Unmarshal(value, &carrier); err != nil {
+			return err
+		}
+		object.Set(strings.TrimPrefix(key, keywordPrefix), carrier.Default)
+	}
+	if patterns.Len() == 0 {
+		object.Delete("patternProperties")
+		return nil
+	}
+	raw, err := json.Marshal(patterns)
+	if err != nil {
+		return err
+	}
+	object.Set("patternProperties", raw)
+	return nil
+}
@@ -0,0 +1,24 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// failFastAbort is the panic value that aborts the generation in fail-fast mode
+type failFastAbort struct {
+	err error
+}
+
+// checkFailFast aborts the generation in fail-fast mode if errors were added to the package
+// since it had numErrors errors. The first of them is reported, and the others are dropped.
+func (context *GeneratorContext) checkFailFast(pkg *loader.Package, numErrors int) {
+	if !context.failFast || len(pkg.Errors) <= numErrors {
+		return
+	}
+	err := pkg.Errors[numErrors]
+	pkg.Errors = pkg.Errors[:numErrors]
+	panic(failFastAbort{err: err})
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+)
+
+// FieldObjName is the name of the document that the type of a field is split into
+type FieldObjName string
+
+// ObjectRefLink requests that the given type is emitted as a standalone document
+// with the given name, and returns the link to that document
+func (context *GeneratorContext) ObjectRefLink(name string, to crd.TypeIdent) (string, error) {
+	documentName := name + context.extension
+	if typeIdent, exists := context.fieldObjects[documentName]; exists && typeIdent != to {
+		return Empty, fmt.Errorf("document %s is already used for type %s", documentName, typeIdent)
+	}
+	context.fieldObjects[documentName] = to
+	context.NeedSchemaFor(to)
+	return context.documentURI(documentName), nil
+}
+
+// addFieldObjects adds a standalone document for each type that a field is split into
+func (context *GeneratorContext) addFieldObjects(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) {
+	for documentName, typeIdent := range context.fieldObjects {
+		if _, exists := documents[documentName]; exists {
+			typeIdent.Package.AddError(fmt.Errorf("document %s of type %s already exists", documentName, typeIdent))
+			continue
+		}
+		schema := context.parser.Schemata[typeIdent]
+		document := schema.DeepCopy()
+		// local references of the type are relative to the document that defines it
+		prefixLocalRefs(document, context.homeDocumentRef(typeIdent.Package))
+		document.Title = documentName
+		documents[documentName] = document
+		objectDocuments[documentName] = true
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"errors"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// draft202012 is the dialect whose vocabularies include format assertion
+	draft202012 = "https://json-schema.org/draft/2020-12/schema"
+	// formatAssertionDocumentBase is the name of the meta-schema that enables format assertion
+	formatAssertionDocumentBase = "format-assertion"
+)
+
+// formatAssertionVocabularies are the vocabularies of draft 2020-12, with format assertion instead of annotation
+var formatAssertionVocabularies = map[string]bool{
+	"https://json-schema.org/draft/2020-12/vocab/core":             true,
+	"https://json-schema.org/draft/2020-12/vocab/applicator":       true,
+	"https://json-schema.org/draft/2020-12/vocab/unevaluated":      true,
+	"https://json-schema.org/draft/2020-12/vocab/validation":       true,
+	"https://json-schema.org/draft/2020-12/vocab/meta-data":        true,
+	"https://json-schema.org/draft/2020-12/vocab/format-assertion": true,
+	"https://json-schema.org/draft/2020-12/vocab/content":          true,
+}
+
+// addFormatAssertion adds a meta-schema that enables the format assertion vocabulary of draft 2020-12,
+// and sets it as the `$schema` of all documents, so that validators treat `format` as an assertion.
+//
+// `$schema` must be an absolute URI, so the meta-schema is placed under the external base URI.
+func (context *GeneratorContext) addFormatAssertion(documents map[string]*apiext.JSONSchemaProps, recursiveRefs bool) error {
+	if context.externalBaseURI == Empty {
+		return errors.New("format assertion requires an external base URI for the URI of its meta-schema")
+	}
+	if recursiveRefs {
+		return errors.New("format assertion requires draft 2020-12, which replaced $recursiveRef")
+	}
+	documentName := formatAssertionDocumentBase + context.extension
+	metaSchemaURI := strings.TrimSuffix(context.externalBaseURI, "/") + "/" + documentName
+	for _, document := range documents {
+		document.Schema = apiext.JSONSchemaURL(metaSchemaURI)
+	}
+
+	dialect := draft202012
+	metaSchema := &apiext.JSONSchemaProps{
+		Schema: draft202012,
+		Title:  documentName,
+		AllOf:  []apiext.JSONSchemaProps{{Ref: &dialect}},
+	}
+	for name, value := range map[string]interface{}{
+		"$id":            metaSchemaURI,
+		"$vocabulary":    formatAssertionVocabularies,
+		"$dynamicAnchor": "meta",
+	} {
+		if err := setKeyword(metaSchema, name, value); err != nil {
+			return err
+		}
+	}
+	documents[documentName] = metaSchema
+	return nil
+}
@@ -4,16 +4,20 @@
 package schemas
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/types"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	orderedmap "github.com/wk8/go-ordered-map/v2"
+	"golang.org/x/tools/go/packages"
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/controller-tools/pkg/crd"
 	crdmarkers "sigs.k8s.io/controller-tools/pkg/crd/markers"
@@ -23,22 +27,275 @@ import (
 )
 
 var (
-	externalDocumentName = "external.json"
+	externalDocumentBase = "external"
+	defaultExtension     = ".json"
+	enumMarkerName       = "fybrik:validation:enum"
+	pointerRefEncoding   = "pointer"
+	percentRefEncoding   = "percent"
 	schemaMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:schema", markers.DescribesPackage, struct{}{}))
-	objectMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:object", markers.DescribesType, ObjName(Empty)))
+	objectMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:object", markers.DescribesType, markers.RawArguments(nil)))
+	timeFormatMarker     = markers.Must(markers.MakeDefinition("fybrik:validation:timeFormat", markers.DescribesField, TimeFormat(Empty)))
+	groupMarker          = markers.Must(markers.MakeDefinition("fybrik:validation:group", markers.DescribesPackage, Empty))
+	keyMaxLengthMarker   = markers.Must(markers.MakeDefinition("fybrik:validation:key:MaxLength", markers.DescribesField, KeyMaxLength(0)))
+	keyMinLengthMarker   = markers.Must(markers.MakeDefinition("fybrik:validation:key:MinLength", markers.DescribesField, KeyMinLength(0)))
+	keyPatternMarker     = markers.Must(markers.MakeDefinition("fybrik:validation:key:Pattern", markers.DescribesField, KeyPattern(Empty)))
+	keyEnumMarker        = markers.Must(markers.MakeDefinition("fybrik:validation:key:Enum", markers.DescribesField, KeyEnum(nil)))
+	titleMarker          = markers.Must(markers.MakeDefinition("fybrik:validation:title", markers.DescribesField, Title(Empty)))
+	enumFieldMarker      = markers.Must(markers.MakeDefinition(enumMarkerName, markers.DescribesField, markers.RawArguments(nil)))
+	enumTypeMarker       = markers.Must(markers.MakeDefinition(enumMarkerName, markers.DescribesType, markers.RawArguments(nil)))
+	objectFieldMarker    = markers.Must(markers.MakeDefinition(objectMarker.Name, markers.DescribesField, FieldObjName(Empty)))
+	secretMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:secret", markers.DescribesField, struct{}{}))
+	sharedDefMarker      = markers.Must(markers.MakeDefinition("fybrik:validation:sharedDef", markers.DescribesType, struct{}{}))
+	exactlyOneOfMarker   = markers.Must(markers.MakeDefinition("fybrik:validation:exactlyOneOf", markers.DescribesType, ExactlyOneOf(nil)))
+	oneOfMarker          = markers.Must(markers.MakeDefinition("fybrik:validation:oneOf", markers.DescribesType, OneOfTypes(nil)))
+	oneOfFieldMarker     = markers.Must(markers.MakeDefinition("fybrik:validation:oneOf", markers.DescribesField, OneOfTypes(nil)))
+	maxBytesMarker       = markers.Must(markers.MakeDefinition("fybrik:validation:maxBytes", markers.DescribesType, MaxBytes(0)))
+	shapeMarker          = markers.Must(markers.MakeDefinition("fybrik:validation:shape", markers.DescribesField, markers.RawArguments(nil)))
+	patternPropMarker    = markers.Must(markers.MakeDefinition("fybrik:validation:patternProperty", markers.DescribesField,
+		markers.RawArguments(nil)))
+	extraValuesMarker = markers.Must(markers.MakeDefinition("fybrik:validation:extraValues", markers.DescribesType,
+		markers.RawArguments(nil)))
 )
 
-type ObjName string
+// ObjName is the argument of the object marker: the name of the object document, and optionally a title
+// for it. The marker takes either the name as a string or a JSON object, e.g. {"name": "sample_crd", "title": "Sample CRD"}
+type ObjName struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+}
 
-func (n ObjName) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
-	schema.Title = string(n)
-	return nil
+// parseObjName parses the argument of the object marker of a type
+func parseObjName(rawObjName markers.RawArguments) (ObjName, error) {
+	var objName ObjName
+	trimmed := strings.TrimSpace(string(rawObjName))
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		if err := json.Unmarshal(rawObjName, &objName); err != nil {
+			return objName, fmt.Errorf("invalid object %s, expected a name or a JSON object with a name and a title: %w", trimmed, err)
+		}
+	case strings.HasPrefix(trimmed, `"`):
+		if err := json.Unmarshal(rawObjName, &objName.Name); err != nil {
+			return objName, fmt.Errorf("invalid object name %s: %w", trimmed, err)
+		}
+	default:
+		objName.Name = trimmed
+	}
+	if objName.Name == Empty {
+		return objName, fmt.Errorf("missing object name in %s", trimmed)
+	}
+	return objName, nil
 }
 
 // Generator generates JSON schema objects.
 type Generator struct {
 	OutputDir string
 
+	// Archive is the name of a .zip or .tar.gz file in OutputDir to write all the documents to,
+	// instead of writing each document to a separate file
+	Archive string
+
+	// RefAliases is the path of a JSON file that maps the `<pkgPath>.<TypeName>` names of types
+	// that moved to another package to their current names. The old names are kept as definitions
+	// in external.json that reference the current ones.
+	RefAliases string
+
+	// CoalesceCycles merges the definitions of documents that reference each other, directly or through
+	// other documents, into a single document, since some loaders reject cycles of references between documents
+	CoalesceCycles bool
+
+	// ExternalBaseURI is a base URI to set the `$id` of external.json under.
+	// When set, references to external.json are absolute URIs.
+	ExternalBaseURI string
+
+	// IDBase is a base URI, such as https://schemas.example.com/v1/, to set the `$id` of each document under,
+	// as `<IDBase><documentName>`. When set, references between documents are absolute URIs under it, while
+	// references within a document stay relative. ExternalBaseURI takes precedence for external.json
+	IDBase string
+
+	// PruneUnreferenced removes the definitions of managed packages and external.json that are not
+	// transitively referenced from a type with the object marker or from a type in a root package
+	// without the schema marker
+	PruneUnreferenced bool
+
+	// Extension is the suffix of the document names, including the leading dot.
+	// Left unspecified, the default is ".json", or ".yaml" in the YAML format
+	Extension *string
+
+	// Format is the serialization of the documents: "json" or "yaml", which keeps the order of the keys of JSON.
+	// Left unspecified, the default is "json"
+	Format string
+
+	// HeaderComment is a comment, such as "AUTO-GENERATED, DO NOT EDIT", to start the YAML documents with.
+	// Each of its lines is prefixed with `#`. JSON has no comments, so it is ignored in the JSON format
+	HeaderComment string
+
+	// RecursiveRefs references self-recursive types with the `$recursiveRef` keyword of
+	// draft 2019-09 instead of `$ref`, and sets the `$schema` of all documents to draft 2019-09
+	RecursiveRefs bool
+
+	// RefEncoding is the encoding of the definition names in the fragments of references:
+	// "pointer" escapes them as JSON pointer tokens, "percent" additionally percent-encodes them.
+	// Left unspecified, the default is "pointer"
+	RefEncoding string
+
+	// ExampleTag is the name of a struct tag whose value is set as the example of the field,
+	// parsed as JSON or else taken as a string
+	ExampleTag string
+
+	// ValidateExamples fails the generation if the example of a schema, such as the example of a field from
+	// the ExampleTag, violates the schema
+	ValidateExamples bool
+
+	// Validate fails the generation if an object document, along with the documents that it references,
+	// doesn't compile as a schema, such as a document with a malformed pattern
+	Validate bool
+
+	// StripK8sExtensions clears all the `x-kubernetes-*` extensions from the generated documents
+	StripK8sExtensions bool
+
+	// HoistAnonymous replaces the structurally identical anonymous structs of a document with references to
+	// a single definition, named after the containing type and field that sort first
+	HoistAnonymous bool
+
+	// Redact removes the data values, i.e., the defaults and examples, from the generated documents,
+	// e.g., for documents that are published externally
+	Redact bool
+
+	// TypeOverrides is the path of a JSON file that maps `<pkgPath>.<TypeName>` names of types to
+	// the schemas that fields of these types are generated with, instead of traversing the types
+	TypeOverrides string
+
+	// NullableCollections permits null for the items of slices of pointers and the values of maps of pointers,
+	// which encoding/json marshals as null when the pointers are nil
+	NullableCollections bool
+
+	// NullableOmitEmpty permits null for omitempty slice and map fields, while the other slice and map fields
+	// keep requiring an array or an object, which rejects the null that encoding/json writes for their nil values
+	NullableOmitEmpty bool
+
+	// NullablePointers permits null for pointer fields, such as `*time.Time` fields, which encoding/json
+	// marshals as null when the pointers are nil
+	NullablePointers bool
+
+	// BigNumbers generates fields of type `big.Float` as JSON numbers instead of strings, for types whose custom
+	// marshaling writes numbers. Fields of type `big.Int`, which is marshaled as a number, are always integers.
+	// Types overridden by TypeOverrides keep their override
+	BigNumbers bool
+
+	// JSONNumbers generates fields of type `json.Number` as JSON numbers or numeric strings instead of strings.
+	// Types overridden by TypeOverrides keep their override
+	JSONNumbers bool
+
+	// SQLNullScalars generates fields of the `sql.Null*` types of database/sql as nullable scalars, for types
+	// whose custom marshaling writes the value or null, instead of traversing their struct definitions
+	SQLNullScalars bool
+
+	// Descriptions is the path of a JSON file with localized descriptions of types and fields,
+	// which override the descriptions from Go comments. The file maps languages to objects
+	// mapping qualified type names, and `<qualifiedName>.<fieldName>` for fields, to descriptions.
+	Descriptions string
+
+	// Lang is the language of the descriptions to use from the Descriptions file
+	Lang string
+
+	// TrailingNewline ends each document with a newline.
+	// Left unspecified, the default is false, so documents end with their closing brace
+	TrailingNewline bool
+
+	// FailFast aborts the generation at the first error, which is returned instead of being
+	// collected with the errors of its package
+	FailFast bool
+
+	// EnumDescriptions sets the enum of types with constants declared in their package, such as
+	// iota enums, to the values of the constants, and the `x-enum-descriptions` keyword to their doc comments
+	EnumDescriptions bool
+
+	// InferEnums sets the enum of string and integer types with exported constants declared in their package, such as
+	// iota enums, to the values of the constants. Float types are included if AllowDangerousTypes is set, and bit flag
+	// types, whose constants are declared with shifts, are left without an enum. Left unspecified, the default is true
+	InferEnums *bool
+
+	// ClosedStyle forbids the properties of structs that their Go definition doesn't declare:
+	// "additional" uses `additionalProperties: false` and leaves structs with embedded bases open,
+	// "unevaluated" closes those with `unevaluatedProperties: false` of draft 2019-09 instead.
+	// Left unspecified, structs are left open
+	ClosedStyle string
+
+	// PropertyCase renames the properties of the schemas to a case: "camel", e.g., `maxRetries`, or "snake", e.g., `max_retries`,
+	// along with their required entries, for JSON tags that don't match the naming of the consumers. The data values,
+	// such as defaults and examples, keep their keys. Left unspecified, the properties keep the names of their JSON tags
+	PropertyCase string
+
+	// PropagateRequired adds the required properties of the embedded bases of structs, which are referenced
+	// with allOf, to the required properties of the structs, for validators that don't evaluate allOf fully
+	PropagateRequired bool
+
+	// HarvestDeprecations sets the `deprecated` keyword of the types and fields whose doc comments have a paragraph
+	// that starts with "Deprecated: ", by Go convention. Their descriptions keep the reason
+	HarvestDeprecations bool
+
+	// AssumeIntWidth is the width in bits, 32 or 64, assumed for the platform-dependent int and uint, which sets
+	// their int32 or int64 format, so that the documents don't depend on the platform. Left unspecified, it is 64
+	AssumeIntWidth int
+
+	// EmptyStruct is the handling of structs without fields: "open" accepts any object, and "closed" only accepts
+	// the empty object. Left unspecified, the default is "open"
+	EmptyStruct string
+
+	// FormatAssertion adds a draft 2020-12 meta-schema with the format assertion vocabulary under ExternalBaseURI,
+	// and sets it as the `$schema` of all documents, so that validators assert `format` rather than annotate with it
+	FormatAssertion bool
+
+	// SchemaDialect sets the `$schema` of the documents to the meta-schema of a dialect: "draft-07", "2019-09" or "2020-12".
+	// Documents whose dialect is set by other options, such as RecursiveRefs, keep it, except that "2020-12" supersedes
+	// draft 2019-09. "2020-12" also moves the definitions under `$defs`. Left unspecified, the default is "draft-07",
+	// unlike an explicit "draft-07", which can't be combined with the options that require a later draft
+	SchemaDialect string
+
+	// DefinitionsRoot is the key of the definitions of each document, such as "$defs", to which the fragments
+	// of references point. Left unspecified, the default is "definitions"
+	DefinitionsRoot string
+
+	// MarkerPrefix replaces the `fybrik:validation` prefix of the markers of the generator, e.g., `+acme:validation:schema`
+	// for the prefix "acme:validation". Left unspecified, the default is "fybrik:validation"
+	MarkerPrefix string
+
+	// MaxDepth limits the number of nested types whose schemas are generated at once, reporting an error for
+	// deeper types rather than expanding them. Left unspecified, the depth is unlimited
+	MaxDepth int
+
+	// Concurrency is the maximum number of root packages that are type-checked and scanned for markers
+	// in parallel. Left unspecified, the root packages are loaded one by one
+	Concurrency int
+
+	// CleanRefs wraps each `$ref` that has sibling keywords, such as a description, in an allOf,
+	// so that the siblings are not ignored
+	CleanRefs bool
+
+	// RootRefOnly adds a root document that only references the documents of the types with
+	// the object marker, as an entry point to all of them
+	RootRefOnly bool
+
+	// GroupByObject moves the document of each type with the object marker into a directory of its own, along with
+	// copies of the documents that it references, which keep only the referenced definitions, e.g.,
+	// `sample_crd/sample_crd.json` and `sample_crd/schemapkg.json`
+	GroupByObject bool
+
+	// EmitManifest adds a manifest.json document that lists the generated documents with their `$id`, title and
+	// definitions, and the documents of the types with the object marker, as a single discovery file
+	EmitManifest bool
+
+	// OpenAPI generates a single openapi document, an OpenAPI 3.1 fragment with the definitions of all documents
+	// and the types with the object marker under `components.schemas`, instead of a document per package
+	OpenAPI bool
+
+	// Summary, if set, is written a summary of the generated documents
+	Summary io.Writer
+
+	// Warnings, if set, is written warnings about likely mistakes in the Go definitions, such as
+	// required fields with the omitempty option
+	Warnings io.Writer
+
 	// AllowDangerousTypes allows types which are usually omitted from CRD generation
 	// because they are not recommended.
 	//
@@ -51,12 +308,52 @@ type Generator struct {
 }
 
 type GeneratorContext struct {
-	ctx     *genall.GenerationContext
-	parser  *crd.Parser
-	typesOM *orderedmap.OrderedMap[crd.TypeIdent, struct{}]
+	ctx    *genall.GenerationContext
+	parser *crd.Parser
+	// Options of the generator, which the post-processing of the documents applies
+	generator Generator
+	typesOM   *orderedmap.OrderedMap[crd.TypeIdent, struct{}]
 	// Array of packages that have a type with object marker
 	objectPkgs []string
 	pkgMarkers map[*loader.Package]markers.MarkerValues
+	// Base URI of external.json, if set
+	externalBaseURI string
+	// Base URI of the `$id` of the documents and of the references between them, if set, ending with a slash
+	idBase string
+	// Use $recursiveRef for self-recursive types
+	recursiveRefs bool
+	// Suffix of the document names
+	extension string
+	// Percent-encode the fragments of references
+	percentRefs bool
+	// Options of the schemas of types
+	schemaOptions schemaOptions
+	// Types of fields that are split into documents of their own, by document name
+	fieldObjects map[string]crd.TypeIdent
+	// Localized descriptions by qualified name, if set
+	descriptions map[string]string
+	// Abort at the first error
+	failFast bool
+	// Derive the enum of types from their constants, with descriptions
+	enumDescriptions bool
+	inferEnums       bool
+	// Maximum number of nested types in the schema of a type, if positive
+	maxDepth int
+	// Types whose schemas are being generated, from the outermost
+	schemaPath []*nestedType
+	// Number of nested types in the generated schema of each type, including itself
+	typeDepths map[crd.TypeIdent]int
+	// Whether the maximum depth was exceeded, which is reported once
+	depthExceeded bool
+	// Prefix of the markers of the generator, if not the default
+	markerPrefix string
+}
+
+// nestedType is a type whose schema is being generated
+type nestedType struct {
+	typ crd.TypeIdent
+	// Maximum depth of the types that its schema references
+	nestedDepth int
 }
 
 func (Generator) CheckFilter() loader.NodeFilter {
@@ -76,26 +373,114 @@ func (Generator) CheckFilter() loader.NodeFilter {
 	}
 }
 
-func (Generator) RegisterMarkers(into *markers.Registry) error {
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
 	// TODO: only register validation markers
 	if err := crdmarkers.Register(into); err != nil {
 		return err
 	}
 
-	if err := markers.RegisterAll(into, schemaMarker, objectMarker); err != nil {
+	if g.MarkerPrefix == Empty || g.MarkerPrefix == defaultMarkerPrefix {
+		return registerFybrikMarkers(into)
+	}
+	return registerPrefixedMarkers(into, g.MarkerPrefix)
+}
+
+// The categories of the help of the markers of the generator
+const (
+	// generationCategory is the category of the markers that select the documents and definitions to generate
+	generationCategory = "JSON schema generation"
+	// validationCategory is the category of the markers that constrain the values of types and fields
+	validationCategory = "JSON schema validation"
+	// annotationCategory is the category of the markers that annotate fields and types without constraining their values
+	annotationCategory = "JSON schema annotations"
+)
+
+// registerFybrikMarkers registers the markers of the generator, under the default prefix
+func registerFybrikMarkers(into *markers.Registry) error {
+	if err := markers.RegisterAll(into, schemaMarker, objectMarker, timeFormatMarker, groupMarker,
+		keyMaxLengthMarker, keyMinLengthMarker, keyPatternMarker, keyEnumMarker, titleMarker,
+		enumFieldMarker, enumTypeMarker, objectFieldMarker, shapeMarker,
+		patternPropMarker, exactlyOneOfMarker, sharedDefMarker, secretMarker, oneOfMarker, oneOfFieldMarker, maxBytesMarker,
+		extraValuesMarker); err != nil {
 		return err
 	}
 	into.AddHelp(schemaMarker,
-		markers.SimpleHelp("object", "enable generation of JSON schema definition for the go structure"))
+		markers.SimpleHelp(generationCategory, "enable generation of JSON schema definition for the go structure"))
 	into.AddHelp(objectMarker,
-		markers.SimpleHelp("object", "enable generation of JSON schema object for the go structure"))
+		markers.SimpleHelp(generationCategory, "enable generation of JSON schema object for the go structure"))
+	into.AddHelp(timeFormatMarker,
+		markers.SimpleHelp(validationCategory, "override the format of a time.Time field: date-time (default), date or unix"))
+	into.AddHelp(groupMarker,
+		markers.SimpleHelp(generationCategory, "group the JSON schema definitions of the package into a document named after the group"))
+	into.AddHelp(keyMaxLengthMarker,
+		markers.SimpleHelp(validationCategory, "specify the maximum length of the keys of a map field"))
+	into.AddHelp(keyMinLengthMarker,
+		markers.SimpleHelp(validationCategory, "specify the minimum length of the keys of a map field"))
+	into.AddHelp(keyPatternMarker,
+		markers.SimpleHelp(validationCategory, "specify a regular expression that the keys of a map field must match"))
+	into.AddHelp(keyEnumMarker,
+		markers.SimpleHelp(validationCategory, "specify the allowed keys of a map field"))
+	into.AddHelp(titleMarker,
+		markers.SimpleHelp(annotationCategory, "specify the title of a field"))
+	into.AddHelp(enumFieldMarker,
+		markers.SimpleHelp(validationCategory, "specify the allowed values of a field as a JSON array"))
+	into.AddHelp(enumTypeMarker,
+		markers.SimpleHelp(validationCategory, "specify the allowed values of a type as a JSON array"))
+	into.AddHelp(objectFieldMarker,
+		markers.SimpleHelp(generationCategory, "split the type of the field into a JSON schema object of its own, referenced by the field"))
+	into.AddHelp(patternPropMarker,
+		markers.SimpleHelp(validationCategory, "specify the schema of the values of a map field for the keys matching a pattern, "+
+			"as a JSON object with a pattern and a schema; may be repeated"))
+	into.AddHelp(extraValuesMarker,
+		markers.SimpleHelp(validationCategory, "accept undeclared properties of a struct type that match a JSON schema, "+
+			"set as its additionalProperties"))
+	into.AddHelp(shapeMarker,
+		markers.SimpleHelp(validationCategory, "specify the schema of a field of an interface type, such as an embedded interface, "+
+			"as a JSON object"))
+	into.AddHelp(sharedDefMarker,
+		markers.SimpleHelp(generationCategory, "emit the JSON schema definition of the type into the shared common.json document, "+
+			"which all documents reference"))
+	into.AddHelp(secretMarker,
+		markers.SimpleHelp(annotationCategory, "mark a credential field as writeOnly with the password format and without an example, "+
+			"like the secret:\"true\" struct tag"))
+	into.AddHelp(exactlyOneOfMarker,
+		markers.SimpleHelp(validationCategory, "specify the properties of a struct of which exactly one must be set, e.g., {\"file\",\"url\"}"))
+	into.AddHelp(oneOfMarker,
+		markers.SimpleHelp(validationCategory, "specify the types that implement an interface type, e.g., {Circle,Square}, "+
+			"or qualified by the import path of another package, so that its values must be one of them"))
+	into.AddHelp(oneOfFieldMarker,
+		markers.SimpleHelp(validationCategory, "specify the types that implement the interface type of a field, "+
+			"e.g., {Circle,\"example.com/shapes.Square\"}, so that its values must be one of them"))
+	into.AddHelp(maxBytesMarker,
+		markers.SimpleHelp(annotationCategory, "specify the maximum size in bytes of the serialized values of a type, "+
+			"emitted as the x-max-bytes extension"))
+	return nil
+}
+
+// unresolvedRootError returns an actionable error if the root package could not be resolved,
+// typically because it is not provided by the current module or by any of its required modules
+func unresolvedRootError(root *loader.Package) error {
+	if len(root.GoFiles) > 0 || len(root.CompiledGoFiles) > 0 {
+		return nil
+	}
+	for _, err := range root.Errors {
+		if err.Kind == packages.ListError {
+			path := root.PkgPath
+			if path == Empty {
+				path = root.ID
+			}
+			return fmt.Errorf("cannot resolve package root %s: make sure that it is provided by the current module "+
+				"or by one of its required modules (e.g., run `go get %s`)", path, path)
+		}
+	}
 	return nil
 }
 
 // Load new types to the ordered map
 func (context *GeneratorContext) loadTypes() {
-	for typeIdent := range context.parser.Types {
+	for typeIdent, info := range context.parser.Types {
 		if _, there := context.typesOM.Get(typeIdent); !there {
+			context.normalizeTypeMarkers(info)
 			context.typesOM.Set(typeIdent, struct{}{})
 		}
 	}
@@ -113,6 +498,123 @@ func (context *GeneratorContext) needPackage(pkg *loader.Package) {
 }
 
 func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	documents, err := g.marshalDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	return g.output(documents)
+}
+
+// GenerateTo generates the JSON schema documents like Generate, but writes each document
+// to the writer that open returns for the document name, instead of to a file in OutputDir.
+func (g Generator) GenerateTo(ctx *genall.GenerationContext, open func(name string) (io.WriteCloser, error)) error {
+	documents, err := g.marshalDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	return g.writeDocuments(documents, open)
+}
+
+// marshalDocuments generates the documents of the scanned packages, marshaled with marshalDocument and keyed
+// by document name, which Generate, GenerateTo and GenerateSchemas output
+func (g Generator) marshalDocuments(ctx *genall.GenerationContext) (map[string][]byte, error) {
+	documents, err := g.generateDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	marshaled := make(map[string][]byte, len(documents))
+	for docName, doc := range documents {
+		data, err := g.marshalDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %s: %w", docName, err)
+		}
+		marshaled[docName] = data
+	}
+	return marshaled, nil
+}
+
+// generateDocuments computes the JSON schema documents of the scanned packages, keyed by document name
+func (g Generator) generateDocuments(ctx *genall.GenerationContext) (documents map[string]*apiext.JSONSchemaProps, err error) {
+	// in fail-fast mode, the generation is aborted at the first error
+	defer func() {
+		if r := recover(); r != nil {
+			abort, isAbort := r.(failFastAbort)
+			if !isAbort {
+				panic(r)
+			}
+			documents, err = nil, abort.err
+		}
+	}()
+	return g.collectDocuments(ctx)
+}
+
+// collectDocuments computes the JSON schema documents for generateDocuments
+func (g Generator) collectDocuments(ctx *genall.GenerationContext) (map[string]*apiext.JSONSchemaProps, error) {
+	if err := g.validateOptions(); err != nil {
+		return nil, err
+	}
+	context, err := g.newContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	context.loadRoots()
+	context.scanTypes()
+	collected, err := context.collectSchemas()
+	if err != nil {
+		return nil, err
+	}
+	if err := context.postProcess(collected); err != nil {
+		return nil, err
+	}
+	return collected.documents, nil
+}
+
+// validateOptions returns an error if an option has an unsupported value, or if options that can't be combined are set
+func (g Generator) validateOptions() error {
+	switch g.Format {
+	case Empty, jsonFormat, yamlFormat:
+	default:
+		return fmt.Errorf("unsupported format %s, expected %s or %s", g.Format, jsonFormat, yamlFormat)
+	}
+	switch g.RefEncoding {
+	case Empty, pointerRefEncoding, percentRefEncoding:
+	default:
+		return fmt.Errorf("unsupported ref encoding %s, expected %s or %s", g.RefEncoding, pointerRefEncoding, percentRefEncoding)
+	}
+	switch g.ClosedStyle {
+	case Empty, additionalClosedStyle, unevaluatedClosedStyle:
+	default:
+		return fmt.Errorf("unsupported closed style %s, expected %s or %s", g.ClosedStyle, additionalClosedStyle, unevaluatedClosedStyle)
+	}
+	switch g.PropertyCase {
+	case Empty, camelPropertyCase, snakePropertyCase:
+	default:
+		return fmt.Errorf("unsupported property case %s, expected %s or %s", g.PropertyCase, camelPropertyCase, snakePropertyCase)
+	}
+	if err := g.checkSchemaDialect(); err != nil {
+		return err
+	}
+	if err := g.checkOpenAPI(); err != nil {
+		return err
+	}
+	if g.GroupByObject && (g.OpenAPI || g.EmitManifest) {
+		return errors.New("the group by object layout can't be combined with the OpenAPI option or the manifest")
+	}
+	switch g.EmptyStruct {
+	case Empty, openEmptyStruct, closedEmptyStruct:
+	default:
+		return fmt.Errorf("unsupported empty struct handling %s, expected %s or %s", g.EmptyStruct, openEmptyStruct, closedEmptyStruct)
+	}
+	switch g.AssumeIntWidth {
+	case 0, 32, 64:
+	default:
+		return fmt.Errorf("unsupported int width %d, expected 32 or 64", g.AssumeIntWidth)
+	}
+	return nil
+}
+
+// newContext creates the context of a generation with validated options, loading the files that the options name
+func (g Generator) newContext(ctx *genall.GenerationContext) (*GeneratorContext, error) {
 	parser := &crd.Parser{
 		Collector:           ctx.Collector,
 		Checker:             ctx.Checker,
@@ -123,26 +625,107 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 	context := &GeneratorContext{
 		ctx:        ctx,
 		parser:     parser,
+		generator:  g,
 		typesOM:    orderedmap.New[crd.TypeIdent, struct{}](),
 		objectPkgs: []string{},
 		pkgMarkers: make(map[*loader.Package]markers.MarkerValues),
+
+		fieldObjects: make(map[string]crd.TypeIdent),
+
+		externalBaseURI: g.ExternalBaseURI,
+		recursiveRefs:   g.RecursiveRefs,
+		extension:       defaultExtension,
+		percentRefs:     g.RefEncoding == percentRefEncoding,
+		failFast:        g.FailFast,
+
+		enumDescriptions: g.EnumDescriptions,
+		inferEnums:       g.InferEnums == nil || *g.InferEnums,
+		maxDepth:         g.MaxDepth,
+		typeDepths:       make(map[crd.TypeIdent]int),
+		markerPrefix:     g.MarkerPrefix,
+
+		schemaOptions: schemaOptions{
+			exampleTag:          g.ExampleTag,
+			nullableCollections: g.NullableCollections,
+			nullableOmitEmpty:   g.NullableOmitEmpty,
+			nullablePointers:    g.NullablePointers,
+			emptyStruct:         g.EmptyStruct,
+			harvestDeprecations: g.HarvestDeprecations,
+			intWidth:            g.AssumeIntWidth,
+			warnings:            g.Warnings,
+		},
+	}
+	if g.Format == yamlFormat {
+		context.extension = yamlExtension
+	}
+	if g.Extension != nil {
+		context.extension = *g.Extension
+	}
+	if g.IDBase != Empty {
+		context.idBase = strings.TrimSuffix(g.IDBase, "/") + "/"
+	}
+
+	if g.TypeOverrides != Empty {
+		typeOverrides, err := loadTypeOverrides(g.TypeOverrides)
+		if err != nil {
+			return nil, err
+		}
+		context.schemaOptions.typeOverrides = typeOverrides
+	}
+	if g.BigNumbers {
+		context.addDefaultOverrides(bigNumberTypes)
+	}
+	if g.JSONNumbers {
+		context.addDefaultOverrides(jsonNumberTypes)
+	}
+	if g.SQLNullScalars {
+		context.addDefaultOverrides(sqlNullTypes)
+	}
+
+	if g.Descriptions != Empty {
+		descriptions, err := loadDescriptions(g.Descriptions, g.Lang)
+		if err != nil {
+			return nil, err
+		}
+		context.descriptions = descriptions
 	}
+	return context, nil
+}
 
-	// Load input packages
-	for _, root := range ctx.Roots {
+// loadRoots loads the root packages and their package markers
+func (context *GeneratorContext) loadRoots() {
+	ctx := context.ctx
+	numErrors := make([]int, len(ctx.Roots))
+	for i, root := range ctx.Roots {
+		numErrors[i] = len(root.Errors)
+	}
+	if context.generator.Concurrency > 1 {
+		preloadRoots(ctx, ctx.Roots, context.generator.Concurrency)
+	}
+	for i, root := range ctx.Roots {
+		numErrors := numErrors[i]
+		if err := unresolvedRootError(root); err != nil {
+			root.AddError(err)
+			context.checkFailFast(root, numErrors)
+			continue
+		}
 		context.needPackage(root)
 		// Load package markers
-		pkgMarkers, err := markers.PackageMarkers(parser.Collector, root)
+		pkgMarkers, err := markers.PackageMarkers(context.parser.Collector, root)
 		if err != nil {
 			root.AddError(err)
 		}
+		context.normalizeMarkers(pkgMarkers)
 		context.pkgMarkers[root] = pkgMarkers
+		context.checkFailFast(root, numErrors)
 	}
+}
 
-	// Scan loaded types
+// scanTypes requests the schemas of the loaded types with the object marker and of the types of packages with the schema marker
+func (context *GeneratorContext) scanTypes() {
 	for pair := context.typesOM.Oldest(); pair != nil; pair = pair.Next() {
 		typeIdent := pair.Key
-		info, knownInfo := parser.Types[typeIdent]
+		info, knownInfo := context.parser.Types[typeIdent]
 		if knownInfo {
 			if info.Markers.Get(objectMarker.Name) != nil {
 				context.objectPkgs = append(context.objectPkgs, typeIdent.Package.PkgPath)
@@ -150,54 +733,148 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 			}
 		}
 		if pkgMarkers, hasMarkers := context.pkgMarkers[typeIdent.Package]; hasMarkers {
-			if pkgMarkers.Get(schemaMarker.Name) != nil {
+			// Note: generic types have no schema of their own, their instantiations are inlined where they are used
+			if pkgMarkers.Get(schemaMarker.Name) != nil && !(knownInfo && isGeneric(info)) {
 				// Loaded type is in a package with fybrik:validation:schema marker
 				// Get a JSON schema from that type (recursive)
 				context.NeedSchemaFor(typeIdent)
 			}
 		}
 	}
+}
+
+// collectedDocuments are the documents of the generated schemas, which postProcess processes
+type collectedDocuments struct {
+	documents map[string]*apiext.JSONSchemaProps
+	// Names of the documents of the types with the object marker
+	objectDocuments map[string]bool
+	// First type of each object document, by document name
+	entryTypes map[string]crd.TypeIdent
+	// Number of types with the object marker
+	objectTypes int
+}
 
-	documents := make(map[string]*apiext.JSONSchemaProps)
+// collectSchemas collects the generated schemas into the documents of their packages, and of the types with
+// the object marker, along with the root document and the documents of fields with the object marker
+func (context *GeneratorContext) collectSchemas() (*collectedDocuments, error) {
+	collected := &collectedDocuments{
+		documents:       make(map[string]*apiext.JSONSchemaProps),
+		objectDocuments: make(map[string]bool),
+		entryTypes:      make(map[string]crd.TypeIdent),
+	}
+	documents := collected.documents
 	//nolint:gocritic
-	for typeIdent, typeSchema := range parser.Schemata {
-		documentName := context.documentNameFor(typeIdent.Package)
+	for typeIdent, typeSchema := range context.parser.Schemata {
+		documentName := context.documentNameForType(typeIdent)
 		document, exists := documents[documentName]
 		if !exists {
-			document = &apiext.JSONSchemaProps{
-				Title:       documentName,
-				Definitions: make(apiext.JSONSchemaDefinitions),
+			var err error
+			document, err = context.newDocument(documentName)
+			if err != nil {
+				return nil, err
 			}
 			documents[documentName] = document
 		}
-		document.Definitions[context.definitionNameFor(documentName, typeIdent)] = typeSchema
+		definitionName := context.definitionNameFor(documentName, typeIdent)
+		if _, duplicate := document.Definitions[definitionName]; duplicate {
+			typeIdent.Package.AddError(fmt.Errorf("duplicate definition %s in document %s", definitionName, documentName))
+		}
+		if documentName == context.sharedDocumentName() {
+			// local references of a shared type are relative to the document of its package
+			typeSchema = *typeSchema.DeepCopy()
+			prefixLocalRefs(&typeSchema, context.homeDocumentRef(typeIdent.Package))
+		}
+		document.Definitions[definitionName] = typeSchema
 
 		// Generate a schema for types with "fybrik:validation:object" marker
-		info, knownInfo := parser.Types[typeIdent]
-		if knownInfo {
-			if info.Markers.Get(objectMarker.Name) != nil {
-				listFields, _ := context.getFields(typeIdent)
-				schemaPtr := parser.Schemata[typeIdent]
-				documentName := fmt.Sprintf("%s.json", schemaPtr.Title)
-				document, exists := documents[documentName]
-				context.removeExtraProps(typeIdent, &schemaPtr, &listFields)
-				if !exists {
-					document = schemaPtr.DeepCopy()
-					document.Title = documentName
-					document.Definitions = make(apiext.JSONSchemaDefinitions)
-					documents[documentName] = document
-				}
+		if info, knownInfo := context.parser.Types[typeIdent]; knownInfo && info.Markers.Get(objectMarker.Name) != nil {
+			context.addObjectDocument(collected, typeIdent, info)
+		}
+	}
 
-				for _, fieldType := range listFields {
-					typeSchemaField := parser.Schemata[fieldType]
-					context.removeExtraProps(fieldType, &typeSchemaField, &listFields)
-					document.Definitions[context.definitionNameFor(documentName, fieldType)] = typeSchemaField
-				}
-			}
+	if context.generator.RootRefOnly {
+		if err := context.addRootDocument(documents, collected.objectDocuments); err != nil {
+			return nil, err
 		}
 	}
 
-	return g.output(documents)
+	context.addFieldObjects(documents, collected.objectDocuments)
+	return collected, nil
+}
+
+// addObjectDocument adds the document of a type with the object marker, with the definitions of the types of its fields
+func (context *GeneratorContext) addObjectDocument(collected *collectedDocuments, typeIdent crd.TypeIdent, info *markers.TypeInfo) {
+	listFields, _ := context.getFields(typeIdent)
+	schemaPtr := context.parser.Schemata[typeIdent]
+	documentName := context.versionedName(schemaPtr.Title, typeIdent.Package) + context.extension
+	collected.objectDocuments[documentName] = true
+	if _, exists := collected.entryTypes[documentName]; !exists {
+		collected.entryTypes[documentName] = typeIdent
+	}
+	collected.objectTypes++
+	document, exists := collected.documents[documentName]
+	context.removeExtraProps(typeIdent, &schemaPtr, &listFields)
+	if !exists {
+		document = schemaPtr.DeepCopy()
+		if homeDocument := context.documentNameFor(typeIdent.Package); homeDocument != context.externalDocumentName() {
+			// local references of a type in a package with the schema marker are relative to the document of its package
+			prefixLocalRefs(document, context.documentURI(homeDocument))
+		}
+		document.Title = documentName
+		// the marker may set a title apart from the document name
+		objName, err := parseObjName(info.Markers.Get(objectMarker.Name).(markers.RawArguments))
+		if err == nil && objName.Title != Empty {
+			document.Title = objName.Title
+		}
+		document.Definitions = make(apiext.JSONSchemaDefinitions)
+		collected.documents[documentName] = document
+	}
+
+	for _, fieldType := range listFields {
+		typeSchemaField := context.parser.Schemata[fieldType]
+		context.removeExtraProps(fieldType, &typeSchemaField, &listFields)
+		document.Definitions[context.definitionNameFor(documentName, fieldType)] = typeSchemaField
+	}
+}
+
+// addDefaultOverrides adds the given schemas of types to the type overrides,
+// unless the types are already overridden
+func (context *GeneratorContext) addDefaultOverrides(schemas map[string]apiext.JSONSchemaProps) {
+	if context.schemaOptions.typeOverrides == nil {
+		context.schemaOptions.typeOverrides = make(map[string]apiext.JSONSchemaProps)
+	}
+	for name, schema := range schemas {
+		if _, isOverridden := context.schemaOptions.typeOverrides[name]; !isOverridden {
+			context.schemaOptions.typeOverrides[name] = schema
+		}
+	}
+}
+
+// nestType records that the schema of the type being generated references a type with the given depth.
+// It returns false, after reporting an error, if the nesting exceeds the maximum depth
+func (context *GeneratorContext) nestType(typ crd.TypeIdent, depth int) bool {
+	if len(context.schemaPath) > 0 {
+		outer := context.schemaPath[len(context.schemaPath)-1]
+		if depth > outer.nestedDepth {
+			outer.nestedDepth = depth
+		}
+	}
+	if context.maxDepth <= 0 || len(context.schemaPath)+depth <= context.maxDepth {
+		return true
+	}
+	if !context.depthExceeded {
+		context.depthExceeded = true
+		err := fmt.Errorf("maximum depth %d exceeded at type %s", context.maxDepth, typ)
+		if len(context.schemaPath) > 0 {
+			path := make([]string, 0, len(context.schemaPath))
+			for _, outer := range context.schemaPath {
+				path = append(path, outer.typ.String())
+			}
+			err = fmt.Errorf("%w, referenced through %s", err, strings.Join(path, " -> "))
+		}
+		typ.Package.AddError(err)
+	}
+	return false
 }
 
 // Get the fields that related to taxonomy (has a taxonomy child)
@@ -315,54 +992,239 @@ func (context *GeneratorContext) removeExtraProps(typeIdent crd.TypeIdent, v *ap
 				}
 			}
 		}
+		// Omit the required list once pruning removed all of its fields
+		if len(v.Required) == 0 {
+			v.Required = nil
+		}
 	}
 }
 
-func (g Generator) output(documents map[string]*apiext.JSONSchemaProps) error {
+func (g Generator) output(documents map[string][]byte) error {
 	// create out dir if needed
 	err := os.MkdirAll(g.OutputDir, os.ModePerm)
 	if err != nil {
 		return err
 	}
+	if g.Archive != Empty {
+		return g.outputArchive(documents)
+	}
 
-	for docName, doc := range documents {
-		outputFilepath := filepath.Clean(filepath.Join(g.OutputDir, docName))
+	// Note: a failed document doesn't stop the others from being written, and the first error is returned
+	var firstErr error
+	for _, docName := range sortedKeys(documents, nil) {
+		if err := writeDocument(filepath.Clean(filepath.Join(g.OutputDir, docName)), documents[docName]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
 
-		// create the file
-		f, err := os.Create(outputFilepath)
+// documentFileMode is the mode that new documents are created with before the umask, like the files of os.Create
+const documentFileMode = 0o666
+
+// writeDocument writes a document atomically, to a temporary file in the directory of the document that
+// replaces it once written, so that a failed write doesn't leave a partial document behind. A replaced
+// document keeps its mode, and a new document has the mode that os.Create would give it.
+func writeDocument(filePath string, data []byte) (err error) {
+	// documents may be grouped in subdirectories, such as the directories of GroupByObject
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := createTemp(dir, "."+filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err != nil {
+			// the error of the write or of the rename is the one to report
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if info, statErr := os.Stat(filePath); statErr == nil && info.Mode().IsRegular() {
+		if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
 			return err
 		}
-		//nolint:gocritic
-		defer func() {
-			if err = f.Close(); err != nil {
-				log.Printf("Error closing file: %s\n", err)
+	}
+	return os.Rename(f.Name(), filePath)
+}
+
+// createTemp creates a new temporary file in the directory, with the documentFileMode under the umask.
+// Note: os.CreateTemp creates files that are only readable by their owner, whatever the umask.
+func createTemp(dir, prefix string) (*os.File, error) {
+	const maxAttempts = 1000
+	for attempt := 0; ; attempt++ {
+		name := filepath.Join(dir, fmt.Sprintf("%s.%d-%d.tmp", prefix, os.Getpid(), time.Now().UnixNano()))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, documentFileMode)
+		if err == nil || !os.IsExist(err) || attempt == maxAttempts {
+			return f, err
+		}
+	}
+}
+
+// writeDocuments writes each document to the writer that open returns for the document name, closing each
+// writer once its document is written. All documents are written, and the first error is returned.
+func (g Generator) writeDocuments(documents map[string][]byte, open func(name string) (io.WriteCloser, error)) error {
+	var firstErr error
+	for _, docName := range sortedKeys(documents, nil) {
+		if err := writeTo(open, docName, documents[docName]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// writeTo writes a document to the writer that open returns for its name, and closes it
+func writeTo(open func(name string) (io.WriteCloser, error), docName string, data []byte) error {
+	f, err := open(docName)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error closing %s: %w", docName, closeErr)
+	}
+	return err
+}
+
+// marshalDocument marshals an indented document, with a trailing newline if TrailingNewline is set, or a YAML
+// document, with the HeaderComment, in the YAML format
+func (g Generator) marshalDocument(doc *apiext.JSONSchemaProps) ([]byte, error) {
+	data, err := marshalJSONDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	if g.Format == yamlFormat {
+		// Note: YAML documents always end with a newline
+		data, err = jsonToYAML(data)
+		if err != nil || g.HeaderComment == Empty {
+			return data, err
+		}
+		return append(yamlComment(g.HeaderComment), data...), nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, Empty, "  "); err != nil {
+		return nil, err
+	}
+	if g.TrailingNewline {
+		indented.WriteByte('\n')
+	}
+	return indented.Bytes(), nil
+}
+
+// marshalJSONDocument marshals a compact JSON document, with the keywords set with setKeyword
+func marshalJSONDocument(doc *apiext.JSONSchemaProps) ([]byte, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return liftKeywords(data)
+}
+
+// rootDefinitions returns the definitions of the types in root packages without the schema marker
+func (context *GeneratorContext) rootDefinitions() []definitionRef {
+	roots := []definitionRef{}
+	for _, root := range context.ctx.Roots {
+		documentName := context.documentNameFor(root)
+		if documentName != context.externalDocumentName() {
+			continue
+		}
+		for typeIdent := range context.parser.Schemata {
+			if typeIdent.Package == root {
+				documentName := context.documentNameForType(typeIdent)
+				roots = append(roots, definitionRef{
+					document:   documentName,
+					definition: context.definitionNameFor(documentName, typeIdent),
+				})
 			}
-		}()
+		}
+	}
+	return roots
+}
+
+// newDocument creates an empty document with the given name
+func (context *GeneratorContext) newDocument(documentName string) (*apiext.JSONSchemaProps, error) {
+	document := &apiext.JSONSchemaProps{
+		Title:       documentName,
+		Definitions: make(apiext.JSONSchemaDefinitions),
+	}
+	if documentName == context.externalDocumentName() && context.externalBaseURI != Empty {
+		if err := setKeyword(document, "$id", context.externalDocumentURI()); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+// externalDocumentURI returns the absolute URI of external.json under the external base URI
+func (context *GeneratorContext) externalDocumentURI() string {
+	return strings.TrimSuffix(context.externalBaseURI, "/") + "/" + context.externalDocumentName()
+}
 
-		bytes, err := json.MarshalIndent(doc, Empty, "  ")
+// documentURI returns the URI that other documents reference a document by: the absolute URI of external.json
+// under the external base URI, if set, or else the document name, under IDBase if set
+func (context *GeneratorContext) documentURI(documentName string) string {
+	if documentName == context.externalDocumentName() && context.externalBaseURI != Empty {
+		return context.externalDocumentURI()
+	}
+	return context.idBase + documentName
+}
+
+// setDocumentIDs sets the `$id` of the documents without one under IDBase
+func (context *GeneratorContext) setDocumentIDs(documents map[string]*apiext.JSONSchemaProps) error {
+	for documentName, document := range documents {
+		var id string
+		hasID, err := getKeyword(document, "$id", &id)
 		if err != nil {
 			return err
 		}
-		_, err = f.Write(bytes)
-		if err != nil {
+		if hasID {
+			continue
+		}
+		if err := setKeyword(document, "$id", context.idBase+documentName); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
 func (context *GeneratorContext) documentNameFor(pkg *loader.Package) string {
 	isManaged := context.pkgMarkers[pkg].Get(schemaMarker.Name) != nil
 	if isManaged {
-		return fmt.Sprintf("%s.json", pkg.Name)
+		if group := context.groupFor(pkg); group != Empty {
+			return context.versionedName(group, pkg) + context.extension
+		}
+		return context.versionedName(pkg.Name, pkg) + context.extension
 	}
-	return externalDocumentName
+	return context.externalDocumentName()
+}
+
+// externalDocumentName returns the name of the document of types in packages without the schema marker
+func (context *GeneratorContext) externalDocumentName() string {
+	return externalDocumentBase + context.extension
+}
+
+// groupFor returns the group of a package as set by the `fybrik:validation:group` marker,
+// falling back to the kubebuilder `groupName` marker
+func (context *GeneratorContext) groupFor(pkg *loader.Package) string {
+	for _, name := range []string{groupMarker.Name, "groupName"} {
+		if group, isSet := context.pkgMarkers[pkg].Get(name).(string); isSet && group != Empty {
+			return group
+		}
+	}
+	return Empty
 }
 
 func (context *GeneratorContext) definitionNameFor(documentName string, typeIdent crd.TypeIdent) string {
-	if documentName == externalDocumentName {
+	if documentName == context.externalDocumentName() {
 		return qualifiedName(loader.NonVendorPath(typeIdent.Package.PkgPath), typeIdent.Name)
 	}
 	return typeIdent.Name
@@ -379,13 +1241,42 @@ func qualifiedName(pkgName, typeName string) string {
 	return typeName
 }
 
+// escapeJSONPointer escapes a reference token of a JSON pointer according to RFC 6901
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// definitionFragment returns the fragment of a reference to the given definition name
+func (context *GeneratorContext) definitionFragment(definitionName string) string {
+	// qualified names contain `~` and `/` characters, which must be escaped in a JSON pointer
+	token := escapeJSONPointer(definitionName)
+	if context.percentRefs {
+		token = percentEncode(token)
+	}
+	return "#/definitions/" + token
+}
+
+// percentEncode percent-encodes all the bytes of s except ASCII letters, digits, `-`, `.` and `_`
+func percentEncode(s string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
 func (context *GeneratorContext) TypeRefLink(from *loader.Package, to crd.TypeIdent) string {
 	fromDocument := context.documentNameFor(from)
-	toDocument := context.documentNameFor(to.Package)
+	toDocument := context.documentNameForType(to)
 
-	prefix := "#/definitions/"
+	prefix := Empty
 	if fromDocument != toDocument {
-		prefix = toDocument + prefix
+		prefix = context.documentURI(toDocument)
 	}
 	// Build the suffix string as a <typeName> if the type is in a package with
 	// the `schema` marker or in a package with a type that has the `object` marker
@@ -394,7 +1285,7 @@ func (context *GeneratorContext) TypeRefLink(from *loader.Package, to crd.TypeId
 	if indexOf(to.Package.PkgPath, context.objectPkgs) == -1 {
 		suffix = context.definitionNameFor(toDocument, to)
 	}
-	return prefix + suffix
+	return prefix + context.definitionFragment(suffix)
 }
 
 func (context *GeneratorContext) NeedSchemaFor(typ crd.TypeIdent) {
@@ -402,6 +1293,7 @@ func (context *GeneratorContext) NeedSchemaFor(typ crd.TypeIdent) {
 
 	context.needPackage(typ.Package)
 	if _, knownSchema := context.parser.Schemata[typ]; knownSchema {
+		context.nestType(typ, context.typeDepths[typ])
 		return
 	}
 
@@ -410,21 +1302,55 @@ func (context *GeneratorContext) NeedSchemaFor(typ crd.TypeIdent) {
 		typ.Package.AddError(fmt.Errorf("unknown type %s", typ))
 		return
 	}
+	if isGeneric(info) {
+		typ.Package.AddError(loader.ErrFromNode(fmt.Errorf(
+			"generic type %q has no schema of its own, use an instantiation such as %s[int] in a field", info.Name, info.Name), info.RawSpec))
+		return
+	}
+
+	// limit the nesting of distinct types, which the WIP schemas below don't bound
+	if !context.nestType(typ, 1) {
+		return
+	}
+	nested := &nestedType{typ: typ}
+	context.schemaPath = append(context.schemaPath, nested)
+	defer func() {
+		context.schemaPath = context.schemaPath[:len(context.schemaPath)-1]
+		context.typeDepths[typ] = nested.nestedDepth + 1
+		context.nestType(typ, nested.nestedDepth+1)
+	}()
 
 	// avoid tripping recursive schemata, like ManagedFields, by adding an empty WIP schema
 	p.Schemata[typ] = apiext.JSONSchemaProps{}
 
-	schemaCtx := newSchemaContext(typ.Package, context, p.AllowDangerousTypes)
+	schemaCtx := newSchemaContext(typ.Package, context, p.AllowDangerousTypes, context.schemaOptions)
 	ctxForInfo := schemaCtx.ForInfo(info)
 
 	pkgMarkers, err := markers.PackageMarkers(p.Collector, typ.Package)
 	if err != nil {
 		typ.Package.AddError(err)
 	}
+	context.normalizeMarkers(pkgMarkers)
 	ctxForInfo.PackageMarkers = pkgMarkers
 	context.pkgMarkers[typ.Package] = pkgMarkers
 
+	numErrors := len(typ.Package.Errors)
 	schema := infoToSchema(ctxForInfo)
+	if context.enumDescriptions {
+		if err := context.addEnumDescriptions(typ, schema); err != nil {
+			typ.Package.AddError(err)
+		}
+	}
+	if context.inferEnums {
+		inferEnum(typ, schema, p.AllowDangerousTypes)
+	}
+	context.checkFailFast(typ.Package, numErrors)
+	if context.descriptions != nil {
+		context.localizeDescriptions(typ, schema)
+	}
+	if context.recursiveRefs {
+		context.useRecursiveRef(typ, schema)
+	}
 
 	p.Schemata[typ] = *schema
 }
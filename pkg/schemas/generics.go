@@ -0,0 +1,107 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// isGeneric returns whether the given type has type parameters
+func isGeneric(info *markers.TypeInfo) bool {
+	return info.RawSpec != nil && info.RawSpec.TypeParams != nil && len(info.RawSpec.TypeParams.List) > 0
+}
+
+// GenericInfo returns the information of the given generic type and the markers of its package
+func (context *GeneratorContext) GenericInfo(typ crd.TypeIdent) (*markers.TypeInfo, markers.MarkerValues) {
+	context.needPackage(typ.Package)
+	pkgMarkers, hasMarkers := context.pkgMarkers[typ.Package]
+	if !hasMarkers {
+		var err error
+		if pkgMarkers, err = markers.PackageMarkers(context.parser.Collector, typ.Package); err != nil {
+			typ.Package.AddError(err)
+		}
+		context.normalizeMarkers(pkgMarkers)
+		context.pkgMarkers[typ.Package] = pkgMarkers
+	}
+	return context.parser.Types[typ], pkgMarkers
+}
+
+// instantiatedToSchema creates a schema for an instantiation of a generic type, such as Wrapper[int].
+// Instantiations have no definition of their own, so their structure is inlined with the type arguments
+// in place of the type parameters, like the structure of the unnamed types of aliases.  The references
+// of the inlined schema are relative to the package of ctx, where the instantiation is used.
+func instantiatedToSchema(ctx *schemaContext, named *types.Named, node ast.Node) *apiext.JSONSchemaProps {
+	for _, instance := range ctx.instances {
+		if types.Identical(instance, named) {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("recursive generic type %s is not supported", named.String()), node))
+			return &apiext.JSONSchemaProps{}
+		}
+	}
+	typeNameInfo := named.Obj()
+	pkgPath := loader.NonVendorPath(typeNameInfo.Pkg().Path())
+	if typeNameInfo.Pkg() == ctx.pkg.Types {
+		pkgPath = Empty
+	}
+	typeIdent := ctx.typeIdentFor(pkgPath, typeNameInfo.Name())
+	if typeIdent.Package == nil {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unknown package of generic type %s", named.String()), node))
+		return &apiext.JSONSchemaProps{}
+	}
+	info, pkgMarkers := ctx.schemaRequester.GenericInfo(typeIdent)
+	if info == nil {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unknown generic type %s", named.String()), node))
+		return &apiext.JSONSchemaProps{}
+	}
+
+	genericCtx := ctx.ForInfo(info)
+	genericCtx.PackageMarkers = pkgMarkers
+	genericCtx.instances = append(ctx.instances[:len(ctx.instances):len(ctx.instances)], named)
+	var props *apiext.JSONSchemaProps
+	if structType, isStruct := named.Underlying().(*types.Struct); isStruct {
+		props = &apiext.JSONSchemaProps{
+			Type:       "object",
+			Properties: make(map[string]apiext.JSONSchemaProps),
+		}
+		fieldTypes := make([]types.Type, structType.NumFields())
+		for i := range fieldTypes {
+			fieldTypes[i] = structType.Field(i).Type()
+		}
+		if len(fieldTypes) != len(info.Fields) {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unsupported fields of generic type %s", named.String()), node))
+			return props
+		}
+		fieldsToSchema(genericCtx, props, fieldTypes)
+	} else {
+		props = aliasedToSchema(genericCtx, named.Underlying(), node)
+	}
+	applyMarkers(genericCtx, info.Markers, props, node)
+	return props
+}
+
+// importedPackage returns the package with the given path among the direct and indirect imports of a package
+func importedPackage(pkg *loader.Package, pkgPath string) *loader.Package {
+	visited := map[*loader.Package]bool{pkg: true}
+	queue := []*loader.Package{pkg}
+	for len(queue) > 0 {
+		imports := queue[0].Imports()
+		queue = queue[1:]
+		if imported, isImported := imports[pkgPath]; isImported {
+			return imported
+		}
+		for _, imported := range imports {
+			if !visited[imported] {
+				visited[imported] = true
+				queue = append(queue, imported)
+			}
+		}
+	}
+	return nil
+}
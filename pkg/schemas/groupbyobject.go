@@ -0,0 +1,152 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// groupByObject moves the document of each type with the object marker into a directory of its own, named after
+// the document, along with copies of the documents that it references, pruned to the referenced definitions,
+// such as the documents of the fields that are split into documents of their own. References within a directory
+// are relative, and the references of the remaining documents to the moved documents point into their directories.
+func (context *GeneratorContext) groupByObject(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) {
+	objects := []string{}
+	for documentName := range objectDocuments {
+		// Note: the documents of fields are split sub-documents of the objects that reference them
+		if _, isFieldObject := context.fieldObjects[documentName]; !isFieldObject {
+			objects = append(objects, documentName)
+		}
+	}
+	sort.Strings(objects)
+
+	grouped := make(map[string]*apiext.JSONSchemaProps)
+	directories := make(map[string]string)
+	for _, object := range objects {
+		directory := strings.TrimSuffix(object, context.extension)
+		directories[object] = directory
+		bundle := objectBundle(object, documents, objectDocuments)
+		for documentName, document := range bundle {
+			relativizeRefs(document, func(target string) string {
+				if _, isBundled := bundle[target]; isBundled {
+					return target
+				}
+				return Empty
+			})
+			deleteKeyword(document, "$id")
+			grouped[directory+"/"+documentName] = document
+		}
+	}
+
+	for _, object := range objects {
+		delete(documents, object)
+	}
+	for _, document := range documents {
+		relativizeRefs(document, func(target string) string {
+			if directory, isMoved := directories[target]; isMoved {
+				return directory + "/" + target
+			}
+			return Empty
+		})
+	}
+	for documentName, document := range grouped {
+		documents[documentName] = document
+	}
+}
+
+// objectBundle returns copies of an object document and of the documents that it transitively references,
+// which keep only the referenced definitions. Referenced object documents are copied as a whole.
+func objectBundle(object string, documents map[string]*apiext.JSONSchemaProps,
+	objectDocuments map[string]bool) map[string]*apiext.JSONSchemaProps {
+	// Note: a reference without a definition is a reference to a whole document
+	pending := []definitionRef{{document: object}}
+	reached := make(map[definitionRef]bool)
+	for len(pending) > 0 {
+		ref := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		document, exists := documents[ref.document]
+		if !exists {
+			continue
+		}
+		if objectDocuments[ref.document] {
+			ref.definition = Empty
+		}
+		if reached[ref] {
+			continue
+		}
+		schema := document
+		if ref.definition != Empty {
+			definition, exists := document.Definitions[ref.definition]
+			if !exists {
+				continue
+			}
+			schema = &definition
+		}
+		reached[ref] = true
+		walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+			if subschema.Ref == nil {
+				return
+			}
+			if definition, isDefinition := parseRef(ref.document, *subschema.Ref); isDefinition {
+				pending = append(pending, definition)
+			} else if documentPart, fragment, _ := strings.Cut(*subschema.Ref, "#"); documentPart != Empty && fragment == Empty {
+				pending = append(pending, definitionRef{document: path.Base(documentPart)})
+			}
+		})
+	}
+
+	return bundleOf(documents, reached)
+}
+
+// bundleOf returns copies of the reached documents, along with copies of the documents of the reached definitions
+// with only those definitions
+func bundleOf(documents map[string]*apiext.JSONSchemaProps, reached map[definitionRef]bool) map[string]*apiext.JSONSchemaProps {
+	bundle := make(map[string]*apiext.JSONSchemaProps)
+	for ref := range reached {
+		if ref.definition == Empty {
+			bundle[ref.document] = documents[ref.document].DeepCopy()
+		}
+	}
+	for ref := range reached {
+		if ref.definition == Empty || reached[definitionRef{document: ref.document}] {
+			continue
+		}
+		document, exists := bundle[ref.document]
+		if !exists {
+			document = documents[ref.document].DeepCopy()
+			document.Definitions = make(apiext.JSONSchemaDefinitions)
+			bundle[ref.document] = document
+		}
+		definition := documents[ref.document].Definitions[ref.definition]
+		document.Definitions[ref.definition] = *definition.DeepCopy()
+	}
+	return bundle
+}
+
+// relativizeRefs replaces the document part of the references to other documents with the name that target
+// returns for the base name of the document, unless it returns an empty name
+func relativizeRefs(document *apiext.JSONSchemaProps, target func(documentName string) string) {
+	walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref == nil {
+			return
+		}
+		documentPart, fragment, found := strings.Cut(*subschema.Ref, "#")
+		if documentPart == Empty {
+			return
+		}
+		documentName := target(path.Base(documentPart))
+		if documentName == Empty {
+			return
+		}
+		ref := documentName
+		if found {
+			ref += "#" + fragment
+		}
+		subschema.Ref = &ref
+	})
+}
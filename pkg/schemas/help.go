@@ -0,0 +1,47 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"sort"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// MarkerHelp describes a marker that the generator supports
+type MarkerHelp struct {
+	Name     string `json:"name"`
+	Target   string `json:"target"`
+	Category string `json:"category,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Details  string `json:"details,omitempty"`
+}
+
+// MarkersHelp lists the markers that the generator registers, under its marker prefix, sorted by name and target
+func (g Generator) MarkersHelp() ([]MarkerHelp, error) {
+	registry := &markers.Registry{}
+	if err := g.RegisterMarkers(registry); err != nil {
+		return nil, err
+	}
+	result := []MarkerHelp{}
+	for _, def := range registry.AllDefinitions() {
+		markerHelp := MarkerHelp{
+			Name:   def.Name,
+			Target: def.Target.String(),
+		}
+		if help := registry.HelpFor(def); help != nil {
+			markerHelp.Category = help.Category
+			markerHelp.Summary = help.Summary
+			markerHelp.Details = help.Details
+		}
+		result = append(result, markerHelp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// propagateRequired adds the required properties of the embedded (allOf) bases of struct schemas, and of
+// their own bases, to the required properties of the structs themselves.  The bases are kept, so that their
+// other keywords still apply, but validators that don't evaluate the `required` keywords of allOf members
+// still see the required properties that a struct inherits.
+func propagateRequired(documents map[string]*apiext.JSONSchemaProps) {
+	var inheritedRequired func(documentName string, schema *apiext.JSONSchemaProps, visiting map[definitionRef]bool) []string
+	inheritedRequired = func(documentName string, schema *apiext.JSONSchemaProps, visiting map[definitionRef]bool) []string {
+		required := append([]string{}, schema.Required...)
+		for i := range schema.AllOf {
+			member := &schema.AllOf[i]
+			if member.Ref == nil {
+				required = append(required, inheritedRequired(documentName, member, visiting)...)
+				continue
+			}
+			base, isDefinition := parseRef(documentName, *member.Ref)
+			if !isDefinition || visiting[base] || documents[base.document] == nil {
+				continue
+			}
+			definition, exists := documents[base.document].Definitions[base.definition]
+			if !exists {
+				continue
+			}
+			visiting[base] = true
+			required = append(required, inheritedRequired(base.document, &definition, visiting)...)
+			delete(visiting, base)
+		}
+		return uniqueStrings(required)
+	}
+
+	for name, document := range documents {
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			if subschema.Type != "object" || len(subschema.AllOf) == 0 {
+				return
+			}
+			// Note: the documents share the schemas of types, so the required properties are replaced rather than appended to
+			if required := inheritedRequired(name, subschema, map[definitionRef]bool{}); len(required) > len(subschema.Required) {
+				subschema.Required = required
+			}
+		})
+	}
+}
+
+// uniqueStrings returns the strings without duplicates, in the order of their first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
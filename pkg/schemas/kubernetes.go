@@ -0,0 +1,21 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// stripK8sExtensions clears the `x-kubernetes-*` extensions of the schema and of its subschemas
+func stripK8sExtensions(schema *apiext.JSONSchemaProps) {
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		subschema.XPreserveUnknownFields = nil
+		subschema.XEmbeddedResource = false
+		subschema.XIntOrString = false
+		subschema.XListType = nil
+		subschema.XListMapKeys = nil
+		subschema.XMapType = nil
+		subschema.XValidations = nil
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// preloadRoots type-checks the given root packages and collects their markers, loading up to concurrency
+// packages in parallel, so that adding the roots to the parser afterwards only reads cached results.
+//
+// Only the loader and the markers collector are used in parallel.  The loader locks each package while
+// type-checking it.  A type checker locks its set of checked packages, but its Check creates the set
+// lazily, without the lock, and passes it to a sub-checker with a mutex of its own, so concurrent calls
+// of Check on a shared checker may write the set under different locks.  Each root is therefore checked
+// with a type checker of its own, which costs little, since the loader type-checks a package reached from
+// several roots only once.  The collector locks its cache of the markers of each package.  The parser isn't
+// safe for concurrent use, so the roots are still added to it one by one, in their order, which also keeps
+// the order of the generated types stable.
+func preloadRoots(ctx *genall.GenerationContext, roots []*loader.Package, concurrency int) {
+	var nodeFilters []loader.NodeFilter
+	if ctx.Checker != nil {
+		nodeFilters = ctx.Checker.NodeFilters
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, root := range roots {
+		if unresolvedRootError(root) != nil {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(root *loader.Package) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			checker := &loader.TypeChecker{NodeFilters: nodeFilters}
+			checker.Check(root)
+			// Note: errors are not cached, the markers are collected again and their errors reported when the root is added
+			_, _ = ctx.Collector.MarkersInPackage(root)
+		}(root)
+	}
+	wg.Wait()
+}
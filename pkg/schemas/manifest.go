@@ -0,0 +1,88 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// manifestDocumentBase is the name, without extension, of the document that lists the generated documents
+const manifestDocumentBase = "manifest"
+
+// manifestDocument describes a generated document in the manifest
+type manifestDocument struct {
+	Name string `json:"name"`
+	// ID is the `$id` of the document, or its name resolved against the external base URI, if set
+	ID          string   `json:"id,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Definitions []string `json:"definitions"`
+}
+
+// manifestEntry is a document of a type with the object marker, or of a type that a field is split into
+type manifestEntry struct {
+	Document string `json:"document"`
+	// Type is the `<pkgPath>.<TypeName>` name of the type
+	Type string `json:"type"`
+}
+
+// manifest lists the generated documents, as a single discovery file for downstream systems
+type manifest struct {
+	Documents []manifestDocument `json:"documents"`
+	Entries   []manifestEntry    `json:"entries"`
+}
+
+// newManifest describes the documents, whose definitions must still be under the default definitions root.
+// entryTypes are the types of the object documents by document name.
+func (context *GeneratorContext) newManifest(documents map[string]*apiext.JSONSchemaProps,
+	entryTypes map[string]crd.TypeIdent) (*manifest, error) {
+	m := &manifest{
+		Documents: []manifestDocument{},
+		Entries:   []manifestEntry{},
+	}
+	for _, documentName := range sortedKeys(documents, nil) {
+		document := documents[documentName]
+		described := manifestDocument{
+			Name:        documentName,
+			Title:       document.Title,
+			Definitions: sortedKeys(document.Definitions, nil),
+		}
+		if _, err := getKeyword(document, "$id", &described.ID); err != nil {
+			return nil, err
+		}
+		if described.ID == Empty && context.externalBaseURI != Empty {
+			described.ID = strings.TrimSuffix(context.externalBaseURI, "/") + "/" + documentName
+		}
+		m.Documents = append(m.Documents, described)
+
+		if typeIdent, isEntry := entryTypes[documentName]; isEntry {
+			m.Entries = append(m.Entries, manifestEntry{
+				Document: documentName,
+				Type:     loader.NonVendorPath(typeIdent.Package.PkgPath) + "." + typeIdent.Name,
+			})
+		}
+	}
+	return m, nil
+}
+
+// addManifestDocument adds the manifest to the documents, with its fields as the keywords of an otherwise empty document
+func (context *GeneratorContext) addManifestDocument(documents map[string]*apiext.JSONSchemaProps, m *manifest) error {
+	manifestDocumentName := manifestDocumentBase + context.extension
+	if _, exists := documents[manifestDocumentName]; exists {
+		return fmt.Errorf("document %s already exists", manifestDocumentName)
+	}
+	document := &apiext.JSONSchemaProps{}
+	if err := setKeyword(document, "documents", m.Documents); err != nil {
+		return err
+	}
+	if err := setKeyword(document, "entries", m.Entries); err != nil {
+		return err
+	}
+	documents[manifestDocumentName] = document
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// TimeFormat overrides the default `date-time` representation of a time.Time field.
+// Valid values are "date-time", "date" and "unix".
+type TimeFormat string
+
+func (TimeFormat) ApplyFirst() {}
+
+func (f TimeFormat) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	if schema.Type != "string" || schema.Format != "date-time" {
+		return fmt.Errorf("timeFormat marker can only be applied to time.Time fields")
+	}
+	switch f {
+	case "date-time", "date":
+		schema.Format = string(f)
+	case "unix":
+		schema.Type = "integer"
+		schema.Format = "int64"
+	default:
+		return fmt.Errorf("unsupported time format %q, expected one of date-time, date or unix", string(f))
+	}
+	return nil
+}
+
+// Title specifies the title of a field.
+type Title string
+
+func (t Title) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	schema.Title = string(t)
+	return nil
+}
+
+// ExactlyOneOf specifies properties of a struct of which exactly one must be set.
+type ExactlyOneOf []string
+
+// ApplyToSchema makes the properties optional, and adds a oneOf with a schema per property,
+// which requires the property and forbids the others
+func (m ExactlyOneOf) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	if schema.Type != "object" || schema.Properties == nil {
+		return errors.New("exactlyOneOf marker can only be applied to struct types")
+	}
+	if len(m) < 2 {
+		return errors.New("exactlyOneOf marker requires at least two properties")
+	}
+	for _, name := range m {
+		if _, exists := schema.Properties[name]; !exists {
+			return fmt.Errorf("exactlyOneOf marker references unknown property %q", name)
+		}
+		if index := indexOf(name, schema.Required); index != -1 {
+			schema.Required = append(schema.Required[:index], schema.Required[index+1:]...)
+		}
+	}
+	if len(schema.Required) == 0 {
+		schema.Required = nil
+	}
+	for _, name := range m {
+		others := make([]apiext.JSONSchemaProps, 0, len(m)-1)
+		for _, other := range m {
+			if other != name {
+				others = append(others, apiext.JSONSchemaProps{Required: []string{other}})
+			}
+		}
+		schema.OneOf = append(schema.OneOf, apiext.JSONSchemaProps{
+			Required: []string{name},
+			Not:      &apiext.JSONSchemaProps{AnyOf: others},
+		})
+	}
+	return nil
+}
+
+// maxBytesKeyword is the extension that holds the maximum size of the serialized values of a type
+const maxBytesKeyword = "x-max-bytes"
+
+// MaxBytes specifies the maximum size in bytes of the serialized values of a type.  JSON schema has no
+// keyword for it, so it is emitted as the x-max-bytes extension, for consumers to enforce.
+type MaxBytes int
+
+func (m MaxBytes) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	if m <= 0 {
+		return fmt.Errorf("maxBytes marker requires a positive size, not %d", int(m))
+	}
+	return setKeyword(schema, maxBytesKeyword, int(m))
+}
+
+// OneOfTypes specifies the types that implement an interface type, whose values are one of them. The types are
+// either of the package of the marker, or qualified by the import path of their package.
+type OneOfTypes []string
+
+// KeyMaxLength specifies the maximum length of the keys of a map field.
+type KeyMaxLength int
+
+// KeyMinLength specifies the minimum length of the keys of a map field.
+type KeyMinLength int
+
+// KeyPattern specifies a regular expression that the keys of a map field must match.
+type KeyPattern string
+
+// KeyEnum specifies the allowed keys of a map field.
+type KeyEnum []string
+
+func (m KeyMaxLength) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	return updatePropertyNames(schema, func(keySchema *apiext.JSONSchemaProps) {
+		maxLength := int64(m)
+		keySchema.MaxLength = &maxLength
+	})
+}
+
+func (m KeyMinLength) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	return updatePropertyNames(schema, func(keySchema *apiext.JSONSchemaProps) {
+		minLength := int64(m)
+		keySchema.MinLength = &minLength
+	})
+}
+
+// ApplyToSchema moves the schema of the values of the map to patternProperties, keyed by the pattern,
+// and disallows additional properties, so that keys which don't match the pattern are rejected
+func (m KeyPattern) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	if !isMapSchema(schema) {
+		return errKeyValidation
+	}
+	if schema.AdditionalProperties.Schema == nil {
+		return errors.New("multiple key patterns")
+	}
+	if schema.PatternProperties == nil {
+		schema.PatternProperties = make(map[string]apiext.JSONSchemaProps)
+	}
+	schema.PatternProperties[string(m)] = *schema.AdditionalProperties.Schema
+	schema.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{Allows: false}
+	return nil
+}
+
+func (m KeyEnum) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	enum := make([]apiext.JSON, 0, len(m))
+	for _, key := range m {
+		raw, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		enum = append(enum, apiext.JSON{Raw: raw})
+	}
+	return updatePropertyNames(schema, func(keySchema *apiext.JSONSchemaProps) {
+		keySchema.Enum = enum
+	})
+}
+
+var errKeyValidation = errors.New("key validation markers can only be applied to map fields")
+
+// isMapSchema checks if a schema is the schema of a map, with its values either
+// in additionalProperties or, once a key pattern is applied, in patternProperties
+func isMapSchema(schema *apiext.JSONSchemaProps) bool {
+	if schema.Type != "object" || schema.AdditionalProperties == nil {
+		return false
+	}
+	if schema.AdditionalProperties.Schema != nil {
+		return true
+	}
+	for name := range schema.PatternProperties {
+		if !strings.HasPrefix(name, keywordPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePropertyNames updates the `propertyNames` schema that constrains the keys of a map schema
+func updatePropertyNames(schema *apiext.JSONSchemaProps, update func(keySchema *apiext.JSONSchemaProps)) error {
+	if !isMapSchema(schema) {
+		return errKeyValidation
+	}
+	keySchema := &apiext.JSONSchemaProps{Type: "string"}
+	if _, err := getKeyword(schema, "propertyNames", keySchema); err != nil {
+		return err
+	}
+	update(keySchema)
+	return setKeyword(schema, "propertyNames", keySchema)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// LoadedPackage returns the package with the given import path among the roots and the packages that they
+// import, such as a package of the types that implement an interface of another package, or nil if it isn't loaded
+func (context *GeneratorContext) LoadedPackage(pkgPath string) *loader.Package {
+	for _, root := range context.ctx.Roots {
+		pkg := root
+		if root.PkgPath != pkgPath {
+			pkg = importedPackage(root, pkgPath)
+		}
+		if pkg != nil {
+			context.needPackage(pkg)
+			return pkg
+		}
+	}
+	return nil
+}
+
+// oneOfToSchema creates a schema whose values are one of the given types, which implement the given interface.
+// The types are either the names of types of the package of ctx, or qualified by the import path of their
+// package, such as `<pkgPath>.<TypeName>`, which may be another root package that doesn't import the interface.
+func oneOfToSchema(ctx *schemaContext, variants OneOfTypes, iface *types.Interface, ifaceName string,
+	node ast.Node) *apiext.JSONSchemaProps {
+	props := &apiext.JSONSchemaProps{}
+	for _, name := range variants {
+		typeIdent, obj, err := ctx.variantFor(name)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+			continue
+		}
+		if iface != nil && !types.Implements(obj.Type(), iface) && !types.Implements(types.NewPointer(obj.Type()), iface) {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("type %q of the oneOf marker doesn't implement %q", name, ifaceName), node))
+			continue
+		}
+		ctx.requestSchema(typeIdent)
+		link := ctx.schemaRequester.TypeRefLink(ctx.pkg, typeIdent)
+		props.OneOf = append(props.OneOf, apiext.JSONSchemaProps{Ref: &link})
+	}
+	return props
+}
+
+// variantFor returns the type of a name listed by the oneOf marker, which may be qualified by the import path of its package
+func (c *schemaContext) variantFor(name string) (crd.TypeIdent, *types.TypeName, error) {
+	pkg, typeName := c.pkg, name
+	if strings.Contains(name, ".") {
+		pkgPath, qualifiedTypeName, err := splitTypeName(name)
+		if err != nil {
+			return crd.TypeIdent{}, nil, err
+		}
+		if pkg = c.schemaRequester.LoadedPackage(pkgPath); pkg == nil {
+			return crd.TypeIdent{}, nil, fmt.Errorf("oneOf marker references type %q of a package that isn't loaded", name)
+		}
+		typeName = qualifiedTypeName
+	}
+	obj, isType := pkg.Types.Scope().Lookup(typeName).(*types.TypeName)
+	if !isType {
+		return crd.TypeIdent{}, nil, fmt.Errorf("oneOf marker references unknown type %q", name)
+	}
+	return crd.TypeIdent{Package: pkg, Name: typeName}, obj, nil
+}
+
+// interfaceFieldToSchema creates a schema for a field of an interface type with the oneOf marker, whose values
+// are one of the listed types, whether or not the interface type has the marker
+func interfaceFieldToSchema(ctx *schemaContext, variants OneOfTypes, field *ast.Field) *apiext.JSONSchemaProps {
+	fieldType := ctx.pkg.TypesInfo.TypeOf(field.Type)
+	iface, isInterface := fieldType.Underlying().(*types.Interface)
+	if !isInterface {
+		ctx.pkg.AddError(loader.ErrFromNode(
+			fmt.Errorf("the %s marker can only be applied to fields of interface types, not %s", oneOfFieldMarker.Name, fieldType), field))
+		return &apiext.JSONSchemaProps{}
+	}
+	ifaceName := fieldType.String()
+	if named, isNamed := fieldType.(*types.Named); isNamed {
+		ifaceName = named.Obj().Name()
+	}
+	return oneOfToSchema(ctx, variants, iface, ifaceName, field)
+}
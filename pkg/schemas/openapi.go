@@ -0,0 +1,151 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// openAPIDocumentBase is the base name of the single document of the OpenAPI option
+	openAPIDocumentBase = "openapi"
+	// openAPIVersion is the version of the OpenAPI document, whose schemas are full JSON schemas, e.g., with a null type
+	openAPIVersion = "3.1.0"
+	// componentsRefPrefix is the prefix of the references to the component schemas
+	componentsRefPrefix = "#/components/schemas/"
+)
+
+// unsafeComponentChars matches the characters that OpenAPI doesn't allow in the keys of components
+var unsafeComponentChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// checkOpenAPI returns an error if the options rely on references that the OpenAPI document doesn't keep
+func (g Generator) checkOpenAPI() error {
+	if !g.OpenAPI {
+		return nil
+	}
+	if g.RecursiveRefs {
+		return errors.New("recursive refs are not supported with the OpenAPI option, since $recursiveRef resolves to the document root")
+	}
+	if g.FormatAssertion {
+		return errors.New("format assertion is not supported with the OpenAPI option, since it requires a meta-schema document")
+	}
+	if g.EmitManifest {
+		return errors.New("the manifest is not supported with the OpenAPI option, which generates a single document")
+	}
+	return nil
+}
+
+// componentKey returns the key of the component of a definition, or of the document itself if the
+// definition is empty. The escaped qualified names of external.json, such as `pkg~1path~0Type`,
+// become `pkg.path.Type`, and other documents prefix the names with their base name, e.g., `schemapkg.SchemaType1`.
+func (context *GeneratorContext) componentKey(documentName, definition string) string {
+	key := strings.TrimSuffix(documentName, context.extension)
+	switch {
+	case definition == Empty:
+	case documentName == context.externalDocumentName():
+		key = strings.NewReplacer("~1", ".", "~0", ".").Replace(definition)
+	default:
+		key += "." + definition
+	}
+	return unsafeComponentChars.ReplaceAllString(key, "_")
+}
+
+// openAPIDocument merges the documents into a single OpenAPI document, with the definitions of all documents,
+// and the roots of the documents with content of their own, such as the documents of the types with
+// the object marker, under `components.schemas`. The references are rewritten to the component schemas.
+func (context *GeneratorContext) openAPIDocument(documents map[string]*apiext.JSONSchemaProps) (*apiext.JSONSchemaProps, error) {
+	components := map[string]apiext.JSONSchemaProps{}
+	addComponent := func(key string, schema apiext.JSONSchemaProps) error {
+		if _, exists := components[key]; exists {
+			return fmt.Errorf("duplicate OpenAPI component %s", key)
+		}
+		components[key] = schema
+		return nil
+	}
+	for _, documentName := range sortedKeys(documents, nil) {
+		document := documents[documentName].DeepCopy()
+		definitions := document.Definitions
+		document.Definitions = nil
+		for _, definition := range sortedKeys(definitions, nil) {
+			schema := definitions[definition]
+			context.rewriteComponentRefs(documentName, definition, &schema, documents)
+			if err := addComponent(context.componentKey(documentName, definition), schema); err != nil {
+				return nil, err
+			}
+		}
+		context.rewriteComponentRefs(documentName, Empty, document, documents)
+		document.Schema = Empty
+		deleteKeyword(document, "$id")
+		root := *document
+		// Note: documents of packages only hold definitions, besides their title
+		root.Title = Empty
+		if !reflect.DeepEqual(root, apiext.JSONSchemaProps{}) {
+			if err := addComponent(context.componentKey(documentName, Empty), *document); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	openAPI := &apiext.JSONSchemaProps{}
+	if err := setKeyword(openAPI, "openapi", openAPIVersion); err != nil {
+		return nil, err
+	}
+	info := map[string]string{"title": openAPIDocumentBase, "version": openAPIVersion}
+	if err := setKeyword(openAPI, "info", info); err != nil {
+		return nil, err
+	}
+	if err := setKeyword(openAPI, "components", map[string]interface{}{"schemas": components}); err != nil {
+		return nil, err
+	}
+	return openAPI, nil
+}
+
+// rewriteComponentRefs rewrites the references of a schema in a document, either its root or the given definition,
+// to definitions and roots of the generated documents to the component schemas. References to documents that
+// weren't generated, such as aliases, are kept.
+func (context *GeneratorContext) rewriteComponentRefs(documentName, definition string, schema *apiext.JSONSchemaProps,
+	documents map[string]*apiext.JSONSchemaProps) {
+	// Note: types of packages with the object marker reference the types of their package by their bare names,
+	// which are qualified by the package in external.json
+	var packagePrefix string
+	if documentName == context.externalDocumentName() {
+		if pkgPath, _, found := strings.Cut(definition, "~0"); found {
+			packagePrefix = pkgPath + "~0"
+		}
+	}
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref == nil {
+			return
+		}
+		var key string
+		if ref, isDefinition := parseRef(documentName, *subschema.Ref); isDefinition {
+			document, isGenerated := documents[ref.document]
+			if !isGenerated {
+				return
+			}
+			if _, exists := document.Definitions[ref.definition]; !exists && ref.document == documentName {
+				if _, isQualified := document.Definitions[packagePrefix+ref.definition]; isQualified {
+					ref.definition = packagePrefix + ref.definition
+				}
+			}
+			key = context.componentKey(ref.document, ref.definition)
+		} else {
+			documentPart, fragment, _ := strings.Cut(*subschema.Ref, "#")
+			target := path.Base(documentPart)
+			if _, isGenerated := documents[target]; documentPart == Empty || fragment != Empty || !isGenerated {
+				return
+			}
+			key = context.componentKey(target, Empty)
+		}
+		ref := componentsRefPrefix + key
+		subschema.Ref = &ref
+	})
+}
@@ -0,0 +1,119 @@
+package schemas
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputDocuments(t *testing.T) {
+	g := Generator{OutputDir: t.TempDir()}
+	documents := sampleDocuments()
+	if err := g.output(sampleData(t)); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	// the documents have the mode of the files that os.Create creates under the umask
+	created, err := os.Create(filepath.Join(t.TempDir(), "created.json"))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	_ = created.Close()
+	createdInfo, err := os.Stat(created.Name())
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	entries, err := os.ReadDir(g.OutputDir)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(g.OutputDir, entry.Name()))
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if info, err := entry.Info(); err != nil || info.Mode().Perm() != createdInfo.Mode().Perm() {
+			t.Errorf("unexpected mode of %s: %v %v", entry.Name(), info.Mode(), err)
+		}
+		checkEntry(t, documents, entry.Name(), content)
+	}
+	for name := range documents {
+		t.Errorf("missing document %s", name)
+	}
+}
+
+func TestOutputKeepsMode(t *testing.T) {
+	g := Generator{OutputDir: t.TempDir()}
+	replaced := filepath.Join(g.OutputDir, "a.json")
+	if err := os.WriteFile(replaced, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if err := g.output(sampleData(t)); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if info, err := os.Stat(replaced); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the mode of the replaced document to be kept, got %v %v", info.Mode(), err)
+	}
+}
+
+func TestOutputErrors(t *testing.T) {
+	// a directory in place of a document can't be replaced, even by a privileged user
+	g := Generator{OutputDir: t.TempDir()}
+	if err := os.MkdirAll(filepath.Join(g.OutputDir, "a.json", "entry"), os.ModePerm); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if err := g.output(sampleData(t)); err == nil || !strings.Contains(err.Error(), "a.json") {
+		t.Errorf("expected an error for a.json, got %v", err)
+	}
+	entries, err := os.ReadDir(g.OutputDir)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "a.json,b.json" {
+		t.Errorf("expected b.json to be written without temporary files, got %v", names)
+	}
+
+	// the writers of GenerateTo are all closed, and the first error is returned
+	closeErr := errors.New("disk full")
+	opened := []string{}
+	err = g.writeDocuments(sampleData(t), func(name string) (io.WriteCloser, error) {
+		opened = append(opened, name)
+		return &failingDocument{err: closeErr}, nil
+	})
+	if !errors.Is(err, closeErr) || !strings.Contains(err.Error(), "error closing a.json") || len(opened) != 2 {
+		t.Errorf("expected the close error of a.json after opening all documents, got %v for %v", err, opened)
+	}
+}
+
+func TestReadOnlyOutputDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("the permissions of directories don't apply to root")
+	}
+	g := Generator{OutputDir: t.TempDir()}
+	if err := os.Chmod(g.OutputDir, 0o555); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	defer func() {
+		_ = os.Chmod(g.OutputDir, 0o755)
+	}()
+	if err := g.output(sampleData(t)); err == nil {
+		t.Error("expected an error for a read-only output directory")
+	}
+}
+
+// failingDocument is a writer whose Close fails
+type failingDocument struct {
+	bytes.Buffer
+	err error
+}
+
+func (d *failingDocument) Close() error {
+	return d.err
+}
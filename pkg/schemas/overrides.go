@@ -0,0 +1,31 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// loadTypeOverrides reads a JSON object that maps `<pkgPath>.<TypeName>` names to schemas
+func loadTypeOverrides(path string) (map[string]apiext.JSONSchemaProps, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]apiext.JSONSchemaProps)
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid type overrides file %s: %w", path, err)
+	}
+	for name := range overrides {
+		if _, _, err := splitTypeName(name); err != nil {
+			return nil, err
+		}
+	}
+	return overrides, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// postProcess applies the options of the generator to the collected documents. The passes run in a fixed order,
+// since later passes read what earlier passes produce, e.g., the manifest lists the documents of the final layout.
+func (context *GeneratorContext) postProcess(collected *collectedDocuments) error {
+	if err := context.reshapeDocuments(collected); err != nil {
+		return err
+	}
+	if err := context.constrainDocuments(collected.documents); err != nil {
+		return err
+	}
+	if err := context.linkDocuments(collected); err != nil {
+		return err
+	}
+	if err := context.checkDocuments(collected); err != nil {
+		return err
+	}
+	return context.layoutDocuments(collected)
+}
+
+// reshapeDocuments prunes the unreferenced definitions, hoists the anonymous structs,
+// strips or redacts the keywords that the options exclude, and renames the properties
+func (context *GeneratorContext) reshapeDocuments(collected *collectedDocuments) error {
+	g, documents := context.generator, collected.documents
+	if g.PruneUnreferenced {
+		pruneUnreferenced(documents, collected.objectDocuments, context.rootDefinitions())
+	}
+
+	if g.HoistAnonymous {
+		if err := hoistAnonymousStructs(documents); err != nil {
+			return err
+		}
+	}
+	// the synthetic names of anonymous structs are only used to hoist them
+	for _, document := range documents {
+		stripAnonymousNames(document)
+	}
+
+	if g.StripK8sExtensions {
+		for _, document := range documents {
+			stripK8sExtensions(document)
+		}
+	}
+
+	if g.Redact {
+		for _, document := range documents {
+			redactValues(document)
+		}
+	}
+
+	if g.PropertyCase != Empty {
+		return renameProperties(documents, g.PropertyCase)
+	}
+	return nil
+}
+
+// constrainDocuments propagates the required properties, closes the structs and wraps the references
+func (context *GeneratorContext) constrainDocuments(documents map[string]*apiext.JSONSchemaProps) error {
+	g := context.generator
+	if g.PropagateRequired {
+		propagateRequired(documents)
+	}
+
+	if g.ClosedStyle != Empty {
+		unevaluated, err := closeStructs(documents, g.ClosedStyle)
+		if err != nil {
+			return err
+		}
+		if unevaluated {
+			for _, document := range documents {
+				document.Schema = draft201909
+			}
+		}
+	}
+
+	if g.CleanRefs {
+		for _, document := range documents {
+			wrapRefs(document)
+		}
+	}
+
+	if g.RecursiveRefs {
+		for _, document := range documents {
+			document.Schema = draft201909
+		}
+	}
+	return nil
+}
+
+// linkDocuments adds the reference aliases, coalesces the cycles, and sets the dialects and the identifiers of the documents
+func (context *GeneratorContext) linkDocuments(collected *collectedDocuments) error {
+	g, documents := context.generator, collected.documents
+	if g.RefAliases != Empty {
+		if err := context.addRefAliases(documents, g.RefAliases); err != nil {
+			return err
+		}
+	}
+
+	if g.CoalesceCycles {
+		if err := coalesceCycles(documents, collected.objectDocuments); err != nil {
+			return err
+		}
+	}
+
+	// schemas from markers and type overrides may declare a dialect, which is only allowed at the document roots
+	for _, document := range documents {
+		stripNestedDialects(document)
+	}
+
+	if g.FormatAssertion {
+		if err := context.addFormatAssertion(documents, g.RecursiveRefs); err != nil {
+			return err
+		}
+	}
+
+	setSchemaDialect(documents, g.SchemaDialect)
+
+	if context.idBase != Empty {
+		return context.setDocumentIDs(documents)
+	}
+	return nil
+}
+
+// checkDocuments validates the examples and the object documents, and writes the summary
+func (context *GeneratorContext) checkDocuments(collected *collectedDocuments) error {
+	g, documents := context.generator, collected.documents
+	if g.ValidateExamples {
+		if err := g.validateExamples(documents); err != nil {
+			return err
+		}
+	}
+
+	if g.Validate {
+		if err := compileObjectDocuments(documents, collected.objectDocuments); err != nil {
+			return err
+		}
+	}
+
+	if g.Summary != nil {
+		summary := newSummary(documents, collected.objectTypes, context.externalDocumentName())
+		return summary.write(g.Summary)
+	}
+	return nil
+}
+
+// layoutDocuments arranges the documents into their output layout, and adds the manifest document
+func (context *GeneratorContext) layoutDocuments(collected *collectedDocuments) error {
+	g := context.generator
+	if g.OpenAPI {
+		openAPI, err := context.openAPIDocument(collected.documents)
+		if err != nil {
+			return err
+		}
+		collected.documents = map[string]*apiext.JSONSchemaProps{openAPIDocumentBase + context.extension: openAPI}
+	}
+	documents := collected.documents
+
+	if g.GroupByObject {
+		context.groupByObject(documents, collected.objectDocuments)
+	}
+
+	var documentsManifest *manifest
+	if g.EmitManifest {
+		for documentName, typeIdent := range context.fieldObjects {
+			collected.entryTypes[documentName] = typeIdent
+		}
+		var err error
+		if documentsManifest, err = context.newManifest(documents, collected.entryTypes); err != nil {
+			return err
+		}
+	}
+
+	// Note: the definitions are moved last, since the processing of the documents reads them
+	if definitionsRoot := g.definitionsRoot(); definitionsRoot != Empty && definitionsRoot != defaultDefinitionsRoot {
+		if err := context.moveDefinitions(documents, definitionsRoot); err != nil {
+			return err
+		}
+	}
+
+	if documentsManifest != nil {
+		return context.addManifestDocument(documents, documentsManifest)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// defaultMarkerPrefix is the prefix of the names of the markers of the generator
+const defaultMarkerPrefix = "fybrik:validation"
+
+// registerPrefixedMarkers registers the markers of the generator under the given prefix instead of
+// the default one. The values of these markers are renamed back by normalizeMarkers once collected,
+// so that they are looked up by the names of the default definitions.
+func registerPrefixedMarkers(into *markers.Registry, prefix string) error {
+	prefix = strings.TrimSuffix(prefix, ":")
+	if prefix == Empty || strings.ContainsAny(prefix, " =") {
+		return fmt.Errorf("invalid marker prefix %q", prefix)
+	}
+	fybrikRegistry := &markers.Registry{}
+	if err := registerFybrikMarkers(fybrikRegistry); err != nil {
+		return err
+	}
+	for _, def := range fybrikRegistry.AllDefinitions() {
+		name := prefix + strings.TrimPrefix(def.Name, defaultMarkerPrefix)
+		prefixed, err := markers.MakeDefinition(name, def.Target, reflect.Zero(def.Output).Interface())
+		if err != nil {
+			return err
+		}
+		if err := into.Register(prefixed); err != nil {
+			return err
+		}
+		into.AddHelp(prefixed, fybrikRegistry.HelpFor(def))
+	}
+	return nil
+}
+
+// normalizeMarkers renames the values of markers with a custom prefix to the names of the default definitions
+func (context *GeneratorContext) normalizeMarkers(values markers.MarkerValues) {
+	prefix := strings.TrimSuffix(context.markerPrefix, ":") + ":"
+	if prefix == ":" || prefix == defaultMarkerPrefix+":" {
+		return
+	}
+	for name, value := range values {
+		if strings.HasPrefix(name, prefix) {
+			values[defaultMarkerPrefix+":"+strings.TrimPrefix(name, prefix)] = value
+			delete(values, name)
+		}
+	}
+}
+
+// normalizeTypeMarkers normalizes the markers of a type and of its fields
+func (context *GeneratorContext) normalizeTypeMarkers(info *markers.TypeInfo) {
+	context.normalizeMarkers(info.Markers)
+	for _, field := range info.Fields {
+		context.normalizeMarkers(field.Markers)
+	}
+}
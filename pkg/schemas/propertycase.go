@@ -0,0 +1,126 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// camelPropertyCase renames properties to camelCase, e.g., `max_retries` to `maxRetries`
+	camelPropertyCase = "camel"
+	// snakePropertyCase renames properties to snake_case, e.g., `maxRetries` to `max_retries`
+	snakePropertyCase = "snake"
+)
+
+// renameProperties renames the properties of all schemas to the given case, in a single pass that renames
+// the keys of `properties` along with the names that refer to them within the same schema: the `required`
+// entries, the keys and property lists of `dependencies` and the `x-kubernetes-list-map-keys` of the items.
+// The names in the required lists of nested schemas, such as the oneOf alternatives of exactlyOneOf, are renamed
+// as they are walked. It returns an error if two properties of a schema are renamed to the same name.
+func renameProperties(documents map[string]*apiext.JSONSchemaProps, style string) error {
+	rename := toCamelCase
+	if style == snakePropertyCase {
+		rename = toSnakeCase
+	}
+	var err error
+	for _, documentName := range sortedKeys(documents, nil) {
+		walkSchema(documents[documentName], func(subschema *apiext.JSONSchemaProps) {
+			if renameErr := renameSchemaProperties(subschema, rename); renameErr != nil && err == nil {
+				err = fmt.Errorf("document %s: %w", documentName, renameErr)
+			}
+		})
+	}
+	return err
+}
+
+// renameSchemaProperties renames the properties of a single schema, without its subschemas
+func renameSchemaProperties(schema *apiext.JSONSchemaProps, rename func(string) string) error {
+	renameAll := func(names []string) []string {
+		if names == nil {
+			return nil
+		}
+		renamed := make([]string, len(names))
+		for i, name := range names {
+			renamed[i] = rename(name)
+		}
+		return renamed
+	}
+
+	if schema.Properties != nil {
+		properties := make(map[string]apiext.JSONSchemaProps, len(schema.Properties))
+		for _, name := range sortedKeys(schema.Properties, nil) {
+			newName := rename(name)
+			if _, duplicate := properties[newName]; duplicate {
+				return fmt.Errorf("properties of %q are renamed to the same name %q", schema.Title, newName)
+			}
+			properties[newName] = schema.Properties[name]
+		}
+		schema.Properties = properties
+	}
+	schema.Required = renameAll(schema.Required)
+	if schema.Dependencies != nil {
+		dependencies := make(apiext.JSONSchemaDependencies, len(schema.Dependencies))
+		for name, dependency := range schema.Dependencies {
+			dependency.Property = renameAll(dependency.Property)
+			dependencies[rename(name)] = dependency
+		}
+		schema.Dependencies = dependencies
+	}
+	schema.XListMapKeys = renameAll(schema.XListMapKeys)
+	return nil
+}
+
+// propertyWords splits a property name into its words, at underscores, dashes and changes of case,
+// keeping acronyms together, e.g., `HTTPServer_url` into `HTTP`, `Server` and `url`
+func propertyWords(name string) []string {
+	words := []string{}
+	runes := []rune(name)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			words = append(words, string(runes[start:end]))
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				flush(i)
+				start = i
+			}
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// toCamelCase returns the camelCase form of a property name, e.g., `maxRetries` for `max_retries`
+func toCamelCase(name string) string {
+	var builder strings.Builder
+	for i, word := range propertyWords(name) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		builder.WriteString(word)
+	}
+	return builder.String()
+}
+
+// toSnakeCase returns the snake_case form of a property name, e.g., `max_retries` for `maxRetries`
+func toSnakeCase(name string) string {
+	return strings.ToLower(strings.Join(propertyWords(name), "_"))
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// definitionRef identifies a definition in a document
+type definitionRef struct {
+	document   string
+	definition string
+}
+
+// unescapeJSONPointer reverts escapeJSONPointer
+func unescapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// parseRef resolves a `$ref` built by TypeRefLink in the given document to the definition it references
+func parseRef(fromDocument, ref string) (definitionRef, bool) {
+	documentPart, fragment, found := strings.Cut(ref, "#")
+	if !found || !strings.HasPrefix(fragment, "/definitions/") {
+		return definitionRef{}, false
+	}
+	document := fromDocument
+	if documentPart != Empty {
+		// references to external.json may be absolute URIs
+		document = path.Base(documentPart)
+	}
+	// the fragment may be percent-encoded
+	token, err := url.PathUnescape(strings.TrimPrefix(fragment, "/definitions/"))
+	if err != nil {
+		return definitionRef{}, false
+	}
+	return definitionRef{
+		document:   document,
+		definition: unescapeJSONPointer(token),
+	}, true
+}
+
+// collectRefs returns the definitions that a schema in the given document references
+func collectRefs(documentName string, schema *apiext.JSONSchemaProps) []definitionRef {
+	refs := []definitionRef{}
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref == nil {
+			return
+		}
+		if ref, ok := parseRef(documentName, *subschema.Ref); ok {
+			refs = append(refs, ref)
+		}
+	})
+	return refs
+}
+
+// pruneUnreferenced removes definitions that are not transitively referenced from the
+// object documents or from the given root definitions. Object documents are kept as is,
+// and documents that are left without definitions are removed.
+func pruneUnreferenced(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool,
+	roots []definitionRef) {
+	pending := roots
+	for documentName := range objectDocuments {
+		pending = append(pending, collectRefs(documentName, documents[documentName])...)
+	}
+
+	reached := make(map[definitionRef]bool)
+	for len(pending) > 0 {
+		ref := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if reached[ref] || objectDocuments[ref.document] {
+			continue
+		}
+		document, exists := documents[ref.document]
+		if !exists {
+			continue
+		}
+		definition, exists := document.Definitions[ref.definition]
+		if !exists {
+			continue
+		}
+		reached[ref] = true
+		pending = append(pending, collectRefs(ref.document, &definition)...)
+	}
+
+	for documentName, document := range documents {
+		if objectDocuments[documentName] {
+			continue
+		}
+		for definitionName := range document.Definitions {
+			if !reached[definitionRef{document: documentName, definition: definitionName}] {
+				delete(document.Definitions, definitionName)
+			}
+		}
+		if len(document.Definitions) == 0 {
+			delete(documents, documentName)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+)
+
+// draft201909 is the dialect that introduced `$recursiveRef`
+const draft201909 = "https://json-schema.org/draft/2019-09/schema"
+
+// useRecursiveRef replaces the references of a self-recursive type to itself with `$recursiveRef`.
+//
+// `$recursiveRef` resolves to the root of the schema resource it's in, so the schema of the type becomes
+// a resource with its own `$id` and a `$recursiveAnchor`. The `$id` is a sibling of the document, so that
+// the other references of the type keep resolving once they are prefixed with the document name.
+func (context *GeneratorContext) useRecursiveRef(typ crd.TypeIdent, schema *apiext.JSONSchemaProps) {
+	selfLink := context.TypeRefLink(typ.Package, typ)
+	isRecursive := false
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		isRecursive = isRecursive || subschema.Ref != nil && *subschema.Ref == selfLink
+	})
+	if !isRecursive {
+		return
+	}
+
+	documentName := context.documentNameFor(typ.Package)
+	var err error
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		switch {
+		case subschema.Ref == nil:
+		case *subschema.Ref == selfLink:
+			subschema.Ref = nil
+			if keywordErr := setKeyword(subschema, "$recursiveRef", "#"); keywordErr != nil {
+				err = keywordErr
+			}
+		case strings.HasPrefix(*subschema.Ref, "#"):
+			ref := documentName + *subschema.Ref
+			subschema.Ref = &ref
+		}
+	})
+	if err == nil {
+		err = setKeyword(schema, "$id", context.definitionNameFor(documentName, typ)+"."+documentName)
+	}
+	if err == nil {
+		err = setKeyword(schema, "$recursiveAnchor", true)
+	}
+	if err != nil {
+		typ.Package.AddError(err)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// redactValues removes the data values, i.e., the defaults and examples, from the schema and from its
+// subschemas, keeping their validation
+func redactValues(schema *apiext.JSONSchemaProps) {
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		subschema.Default = nil
+		subschema.Example = nil
+	})
+}
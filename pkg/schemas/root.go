@@ -0,0 +1,38 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"sort"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// rootDocumentBase is the name, without extension, of the document that references all object documents
+const rootDocumentBase = "root"
+
+// addRootDocument adds a document that only references the documents of the types with the object marker,
+// as an entry point to discover them. Objects may be valid against several of these documents, so the
+// references are combined with anyOf.
+func (context *GeneratorContext) addRootDocument(documents map[string]*apiext.JSONSchemaProps, objectDocuments map[string]bool) error {
+	rootDocument := rootDocumentBase + context.extension
+	if _, exists := documents[rootDocument]; exists {
+		return fmt.Errorf("document %s already exists", rootDocument)
+	}
+	documentNames := make([]string, 0, len(objectDocuments))
+	for documentName := range objectDocuments {
+		documentNames = append(documentNames, documentName)
+	}
+	sort.Strings(documentNames)
+	document := &apiext.JSONSchemaProps{
+		Title: rootDocument,
+	}
+	for _, documentName := range documentNames {
+		ref := context.documentURI(documentName)
+		document.AnyOf = append(document.AnyOf, apiext.JSONSchemaProps{Ref: &ref})
+	}
+	documents[rootDocument] = document
+	return nil
+}
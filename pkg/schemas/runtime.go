@@ -0,0 +1,149 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// LoadOptions control how the package roots are loaded
+type LoadOptions struct {
+	// BuildTags select the files with build constraints to load
+	BuildTags []string
+
+	// Dir is the directory that relative root paths are resolved against,
+	// instead of the current working directory
+	Dir string
+
+	// Tests includes the types declared in the `_test.go` files of the root packages
+	Tests bool
+}
+
+// ForRoots is like genall.Generators.ForRoots, except that the roots are loaded
+// according to the given options.
+func ForRoots(generators genall.Generators, options LoadOptions, rootPaths ...string) (*genall.Runtime, error) {
+	cfg := &packages.Config{Tests: options.Tests}
+	if len(options.BuildTags) > 0 {
+		// keep the tag that the loader sets by default, since it's overridden by ours
+		cfg.BuildFlags = []string{"-tags", strings.Join(append([]string{"ignore_autogenerated"}, options.BuildTags...), ",")}
+	}
+	if options.Dir != Empty {
+		dir, err := filepath.Abs(options.Dir)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Dir = dir
+		resolved := make([]string, 0, len(rootPaths))
+		for _, rootPath := range rootPaths {
+			resolved = append(resolved, resolvePath(dir, rootPath))
+		}
+		rootPaths = resolved
+	}
+	roots, err := loader.LoadRootsWithConfig(cfg, rootPaths...)
+	if err != nil {
+		return nil, err
+	}
+	if options.Tests {
+		roots = testVariants(roots)
+	}
+	rt := &genall.Runtime{
+		Generators: generators,
+		GenerationContext: genall.GenerationContext{
+			Collector: &markers.Collector{
+				Registry: &markers.Registry{},
+			},
+			Roots:     roots,
+			InputRule: genall.InputFromFileSystem,
+			Checker: &loader.TypeChecker{
+				NodeFilters: generators.CheckFilters(),
+			},
+		},
+		OutputRules: genall.OutputRules{Default: genall.OutputToNothing},
+	}
+	if err := rt.Generators.RegisterMarkers(rt.Collector.Registry); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// GenerateSchemas generates the documents of the given roots like Generate, but returns them marshaled and keyed by
+// document name instead of writing them to OutputDir, so that the generator can be embedded in other programs.
+// The roots are loaded according to the given options, and the errors of the loaded packages are returned,
+// rather than printed.
+func GenerateSchemas(g Generator, options LoadOptions, rootPaths ...string) (map[string][]byte, error) {
+	var generators genall.Generators
+	var generator genall.Generator = &g
+	generators = append(generators, &generator)
+	rt, err := ForRoots(generators, options, rootPaths...)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := g.marshalDocuments(&rt.GenerationContext)
+	if err != nil {
+		return nil, err
+	}
+	if err := packageErrors(rt.Roots); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// packageErrors returns an error that lists the errors of the packages and of their imports, except for
+// the type errors, which genall.Runtime.Run skips too, since they may be caused by partial type-checking
+func packageErrors(pkgs []*loader.Package) error {
+	pkgsRaw := make([]*packages.Package, len(pkgs))
+	for i, pkg := range pkgs {
+		pkgsRaw[i] = pkg.Package
+	}
+	var failures []string
+	packages.Visit(pkgsRaw, nil, func(pkgRaw *packages.Package) {
+		for _, err := range pkgRaw.Errors {
+			if err.Kind != packages.TypeError {
+				failures = append(failures, err.Error())
+			}
+		}
+	})
+	if len(failures) > 0 {
+		return errors.New("generator failed with errors:\n" + strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// testVariants keeps a single variant of each package loaded with tests: the variant that is
+// compiled with the `_test.go` files of the package, if there are any, or else the package itself.
+// External test packages and test binaries are dropped.
+func testVariants(roots []*loader.Package) []*loader.Package {
+	ids := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		ids[root.ID] = true
+	}
+	variants := make([]*loader.Package, 0, len(roots))
+	for _, root := range roots {
+		switch {
+		case strings.HasSuffix(root.ID, ".test"), strings.HasSuffix(root.PkgPath, "_test"):
+			// a test binary or an external test package
+		case strings.Contains(root.ID, " ["):
+			variants = append(variants, root)
+		case !ids[root.ID+" ["+root.PkgPath+".test]"]:
+			variants = append(variants, root)
+		}
+	}
+	return variants
+}
+
+// resolvePath resolves a relative filesystem path against dir. Package paths, which
+// are not filesystem paths according to the go command, are returned as is.
+func resolvePath(dir, path string) string {
+	if path == "." || path == ".." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
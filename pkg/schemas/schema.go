@@ -20,11 +20,15 @@ limitations under the License.
 package schemas
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io"
+	"math"
+	"regexp"
 	"strings"
 
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -49,6 +53,82 @@ const (
 // for quick comparison.
 var byteType = types.Universe.Lookup("byte").Type()
 
+var uuidSchema = apiext.JSONSchemaProps{
+	Type:    "string",
+	Format:  "uuid",
+	Pattern: `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+}
+
+var ipSchema = apiext.JSONSchemaProps{
+	Type:  "string",
+	AnyOf: []apiext.JSONSchemaProps{{Format: "ipv4"}, {Format: "ipv6"}},
+}
+
+// Note: wellKnownTypes are named types with a fixed JSON representation that
+// can't be derived by traversing their Go definition. Their schema is inlined
+// instead of referenced, so that field markers can further refine it.
+var wellKnownTypes = map[string]apiext.JSONSchemaProps{
+	"time.Time": {Type: "string", Format: "date-time"},
+	// UUIDs of the common libraries are marshaled as text
+	"github.com/google/uuid.UUID":    uuidSchema,
+	"github.com/gofrs/uuid.UUID":     uuidSchema,
+	"github.com/satori/go.uuid.UUID": uuidSchema,
+	// IP addresses are marshaled as text, while networks have no marshaler and are marshaled as structs,
+	// with the address as text and the mask as a base64 string, like other byte slices
+	"net.IP": ipSchema,
+	"net.IPNet": {
+		Type: "object",
+		Properties: map[string]apiext.JSONSchemaProps{
+			"IP":   ipSchema,
+			"Mask": {Type: "string", Format: "byte"},
+		},
+		Required: []string{"IP", "Mask"},
+	},
+	// RawExtension holds arbitrary embedded JSON objects
+	"k8s.io/apimachinery/pkg/runtime.RawExtension": {
+		Type:                 "object",
+		AdditionalProperties: &apiext.JSONSchemaPropsOrBool{Allows: true},
+	},
+	// arbitrary-precision integers are marshaled as JSON numbers, while arbitrary-precision floats are marshaled as text
+	"math/big.Int":   {Type: "integer"},
+	"math/big.Float": {Type: "string"},
+}
+
+// bigNumberTypes are the schemas of the arbitrary-precision floats as JSON numbers
+var bigNumberTypes = map[string]apiext.JSONSchemaProps{
+	"math/big.Float": {Type: "number"},
+}
+
+// jsonNumberTypes are the schemas of the numbers of encoding/json, which are marshaled as JSON numbers
+// and unmarshaled from JSON numbers or, with the `string` option, from numeric strings
+var jsonNumberTypes = map[string]apiext.JSONSchemaProps{
+	"encoding/json.Number": {
+		OneOf: []apiext.JSONSchemaProps{
+			{Type: "number"},
+			{Type: "string", Pattern: `^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`},
+		},
+	},
+}
+
+// sqlNullTypes are the schemas of the nullable types of database/sql as nullable scalars
+var sqlNullTypes = map[string]apiext.JSONSchemaProps{
+	"database/sql.NullString":  nullableSchema(apiext.JSONSchemaProps{Type: "string"}),
+	"database/sql.NullBool":    nullableSchema(apiext.JSONSchemaProps{Type: "boolean"}),
+	"database/sql.NullByte":    nullableSchema(apiext.JSONSchemaProps{Type: "integer"}),
+	"database/sql.NullInt16":   nullableSchema(apiext.JSONSchemaProps{Type: "integer"}),
+	"database/sql.NullInt32":   nullableSchema(apiext.JSONSchemaProps{Type: "integer", Format: "int32"}),
+	"database/sql.NullInt64":   nullableSchema(apiext.JSONSchemaProps{Type: "integer", Format: "int64"}),
+	"database/sql.NullFloat64": nullableSchema(apiext.JSONSchemaProps{Type: "number"}),
+	"database/sql.NullTime":    nullableSchema(apiext.JSONSchemaProps{Type: "string", Format: "date-time"}),
+}
+
+// nullableSchema permits null in addition to the given schema
+func nullableSchema(schema apiext.JSONSchemaProps) apiext.JSONSchemaProps {
+	return apiext.JSONSchemaProps{
+		AnyOf: []apiext.JSONSchemaProps{schema, {Type: "null"}},
+	}
+}
+
 // SchemaMarker is any marker that needs to modify the schema of the underlying type or field.
 type SchemaMarker interface {
 	// ApplyToSchema is called after the rest of the schema for a given type
@@ -66,6 +146,14 @@ type schemaRequester interface {
 	NeedSchemaFor(typ crd.TypeIdent)
 	// Note(roee88): TypeRefLink extracted to be controlled by caller
 	TypeRefLink(from *loader.Package, to crd.TypeIdent) string
+	// ObjectRefLink splits the given type into a document of its own
+	ObjectRefLink(name string, to crd.TypeIdent) (string, error)
+	// GenericInfo returns the information of the given generic type and the markers of its package
+	GenericInfo(typ crd.TypeIdent) (*markers.TypeInfo, markers.MarkerValues)
+	// NodeMarkers returns the markers of the given node of a package, such as a field of an anonymous struct
+	NodeMarkers(pkg *loader.Package, node ast.Node) markers.MarkerValues
+	// LoadedPackage returns the loaded package with the given import path, or nil if it isn't loaded
+	LoadedPackage(pkgPath string) *loader.Package
 }
 
 // schemaContext stores and provides information across a hierarchy of schema generation.
@@ -76,17 +164,48 @@ type schemaContext struct {
 	schemaRequester schemaRequester
 	PackageMarkers  markers.MarkerValues
 
+	// the instantiations of generic types being inlined, to detect recursive ones
+	instances []*types.Named
+	// the synthetic name of anonymous structs in the type being generated, derived from the containing type and field
+	anonymousName string
+	// the field being generated, for error messages, or empty for the type of ctx.info
+	field string
+
 	allowDangerousTypes bool
+	schemaOptions
+}
+
+// schemaOptions are the options of the generator that affect the schemas of types
+type schemaOptions struct {
+	// Name of the struct tag holding the examples of fields, if set
+	exampleTag string
+	// Schemas of named types by `<pkgPath>.<TypeName>`, which take precedence over the wellKnownTypes
+	typeOverrides map[string]apiext.JSONSchemaProps
+	// Whether the pointer items of slices and pointer values of maps may be null
+	nullableCollections bool
+	// Whether omitempty slice and map fields may be null
+	nullableOmitEmpty bool
+	// Whether pointer fields may be null
+	nullablePointers bool
+	// Whether empty structs accept any object ("open") or only the empty object ("closed")
+	emptyStruct string
+	// Whether the `deprecated` keyword is set for the types and fields with a "Deprecated: " paragraph in their docs
+	harvestDeprecations bool
+	// Width in bits, 32 or 64, of the platform-dependent int and uint, which sets their format
+	intWidth int
+	// Writer of warnings about likely mistakes in the Go definitions, if set
+	warnings io.Writer
 }
 
 // newSchemaContext constructs a new schemaContext for the given package and schema requester.
 // It must have type info added before use via ForInfo.
-func newSchemaContext(pkg *loader.Package, req schemaRequester, allowDangerousTypes bool) *schemaContext {
+func newSchemaContext(pkg *loader.Package, req schemaRequester, allowDangerousTypes bool, options schemaOptions) *schemaContext {
 	pkg.NeedTypesInfo()
 	return &schemaContext{
 		pkg:                 pkg,
 		schemaRequester:     req,
 		allowDangerousTypes: allowDangerousTypes,
+		schemaOptions:       options,
 	}
 }
 
@@ -97,8 +216,21 @@ func (c *schemaContext) ForInfo(info *markers.TypeInfo) *schemaContext {
 		pkg:                 c.pkg,
 		info:                info,
 		schemaRequester:     c.schemaRequester,
+		PackageMarkers:      c.PackageMarkers,
+		instances:           c.instances,
+		anonymousName:       c.anonymousName,
+		field:               c.field,
 		allowDangerousTypes: c.allowDangerousTypes,
+		schemaOptions:       c.schemaOptions,
+	}
+}
+
+// warn writes a warning about the given node, if warnings are enabled
+func (c *schemaContext) warn(node ast.Node, format string, args ...interface{}) {
+	if c.warnings == nil {
+		return
 	}
+	fmt.Fprintf(c.warnings, "%s: warning: %s\n", c.pkg.Fset.Position(node.Pos()), fmt.Sprintf(format, args...))
 }
 
 // requestSchema asks for the schema for a type in the package with the
@@ -106,7 +238,7 @@ func (c *schemaContext) ForInfo(info *markers.TypeInfo) *schemaContext {
 func (c *schemaContext) typeIdentFor(pkgPath, typeName string) crd.TypeIdent {
 	pkg := c.pkg
 	if pkgPath != "" {
-		pkg = c.pkg.Imports()[pkgPath]
+		pkg = importedPackage(c.pkg, pkgPath)
 	}
 	return crd.TypeIdent{
 		Package: pkg,
@@ -122,6 +254,21 @@ func (c *schemaContext) requestSchema(typeIdent crd.TypeIdent) {
 
 // infoToSchema creates a schema for the type in the given set of type information.
 func infoToSchema(ctx *schemaContext) *apiext.JSONSchemaProps {
+	schema := marshaledToSchema(ctx)
+	if schema == nil {
+		schema = typeToSchema(ctx, ctx.info.RawSpec.Type)
+	}
+	if ctx.harvestDeprecations && isDeprecated(typeDocs(ctx.info)...) {
+		if err := setKeyword(schema, "deprecated", true); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, ctx.info.RawSpec))
+		}
+	}
+	return schema
+}
+
+// marshaledToSchema returns the schema of a type that implements a JSON marshaler from its markers, or nil
+// if it has no such markers
+func marshaledToSchema(ctx *schemaContext) *apiext.JSONSchemaProps {
 	// If the obj implements a JSON marshaler and has a marker, use the markers value and do not traverse as
 	// the marshaler could be doing anything. If there is no marker, fall back to traversing.
 	if obj := ctx.pkg.Types.Scope().Lookup(ctx.info.Name); obj != nil && implementsJSONMarshaler(obj.Type()) {
@@ -131,7 +278,7 @@ func infoToSchema(ctx *schemaContext) *apiext.JSONSchemaProps {
 			return schema
 		}
 	}
-	return typeToSchema(ctx, ctx.info.RawSpec.Type)
+	return nil
 }
 
 // applyMarkers applies schema markers to the given schema, respecting "apply first" markers.
@@ -173,13 +320,172 @@ func applyMarkers(ctx *schemaContext, markerSet markers.MarkerValues, props *api
 	}
 
 	// Note(roee88): exchange x-kubernetes-preserve-unknown-fields with additionalProperties: true
-	if props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields {
+	preservesUnknownFields := props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields
+	if preservesUnknownFields {
 		if props.AdditionalProperties == nil {
 			props.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{}
 		}
 		props.AdditionalProperties.Allows = true
 		props.XPreserveUnknownFields = nil
 	}
+
+	applyGeneratorMarkers(ctx, markerSet, props, node, preservesUnknownFields)
+}
+
+// applyGeneratorMarkers applies the markers of the generator that take JSON arguments, and checks the enum
+// and the patterns that the markers set
+func applyGeneratorMarkers(ctx *schemaContext, markerSet markers.MarkerValues, props *apiext.JSONSchemaProps, node ast.Node,
+	preservesUnknownFields bool) {
+	// Note: the extra values marker replaces the additionalProperties of a struct, which preserving the unknown fields also sets
+	if rawExtraValues, isSet := markerSet.Get(extraValuesMarker.Name).(markers.RawArguments); isSet {
+		if preservesUnknownFields {
+			ctx.pkg.AddError(loader.ErrFromNode(
+				fmt.Errorf("the %s marker can't be combined with preserving the unknown fields", extraValuesMarker.Name), node))
+		} else if err := setExtraValues(props, rawExtraValues); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+		}
+	}
+
+	// Note: the fybrik enum marker takes a JSON array, so that its values may contain separators
+	if rawEnum, isSet := markerSet.Get(enumMarkerName).(markers.RawArguments); isSet {
+		enum, err := parseEnum(rawEnum)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+		} else {
+			props.Enum = enum
+		}
+	}
+
+	// Note: the object marker of a type takes a name or a JSON object, so that it may set a title
+	if rawObjName, isSet := markerSet.Get(objectMarker.Name).(markers.RawArguments); isSet {
+		objName, err := parseObjName(rawObjName)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+		} else {
+			props.Title = objName.Name
+		}
+	}
+
+	// Note: the pattern property marker may be repeated, each adding a pattern to patternProperties
+	for _, markerValue := range markerSet[patternPropMarker.Name] {
+		if err := addPatternProperty(props, markerValue.(markers.RawArguments)); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+		}
+	}
+
+	// Note: an enum marker can't change the type of the schema, so its values must match the type
+	if err := checkEnumType(props); err != nil {
+		ctx.pkg.AddError(loader.ErrFromNode(err, node))
+	}
+
+	// Note: a malformed pattern is reported where it is set, rather than when the schema is compiled for validation
+	for _, markerValues := range markerSet {
+		for _, markerValue := range markerValues {
+			if pattern, err := checkPattern(markerValue); err != nil {
+				ctx.pkg.AddError(loader.ErrFromNode(
+					fmt.Errorf("%s has an invalid pattern %q: %w", markedName(ctx, node), pattern, err), node))
+			}
+		}
+	}
+}
+
+// checkPattern returns the value of a pattern marker, and an error if it isn't a valid regular expression
+func checkPattern(markerValue interface{}) (string, error) {
+	var pattern string
+	switch markerValue := markerValue.(type) {
+	case crdmarkers.Pattern:
+		pattern = string(markerValue)
+	case KeyPattern:
+		pattern = string(markerValue)
+	default:
+		return Empty, nil
+	}
+	_, err := regexp.Compile(pattern)
+	return pattern, err
+}
+
+// markedName returns the name of the field or type that markers are applied to, for errors
+func markedName(ctx *schemaContext, node ast.Node) string {
+	if field, isField := node.(*ast.Field); isField && len(field.Names) > 0 {
+		return fmt.Sprintf("field %q of type %q", field.Names[0].Name, ctx.info.Name)
+	}
+	return fmt.Sprintf("type %q", ctx.info.Name)
+}
+
+// addPatternProperty adds the schema of the values of a map for the keys that match a pattern,
+// from the `{"pattern": <pattern>, "schema": <schema>}` argument of the pattern property marker
+func addPatternProperty(props *apiext.JSONSchemaProps, rawPatternProperty markers.RawArguments) error {
+	if !isMapSchema(props) {
+		return fmt.Errorf("the %s marker can only be applied to map fields", patternPropMarker.Name)
+	}
+	var patternProperty struct {
+		Pattern string                  `json:"pattern"`
+		Schema  *apiext.JSONSchemaProps `json:"schema"`
+	}
+	err := json.Unmarshal(rawPatternProperty, &patternProperty)
+	if err != nil || patternProperty.Pattern == Empty || patternProperty.Schema == nil {
+		return fmt.Errorf("invalid pattern property %s, expected a JSON object with a pattern and a schema", string(rawPatternProperty))
+	}
+	if _, exists := props.PatternProperties[patternProperty.Pattern]; exists {
+		return fmt.Errorf("duplicate pattern property %s", patternProperty.Pattern)
+	}
+	if props.PatternProperties == nil {
+		props.PatternProperties = make(map[string]apiext.JSONSchemaProps)
+	}
+	props.PatternProperties[patternProperty.Pattern] = *patternProperty.Schema
+	return nil
+}
+
+// setExtraValues sets the schema of the undeclared properties of a struct from the JSON schema argument of the extra values marker
+func setExtraValues(props *apiext.JSONSchemaProps, rawExtraValues markers.RawArguments) error {
+	if !isStructSchema(props) {
+		return fmt.Errorf("the %s marker can only be applied to struct types", extraValuesMarker.Name)
+	}
+	schema := &apiext.JSONSchemaProps{}
+	if err := json.Unmarshal(rawExtraValues, schema); err != nil {
+		return fmt.Errorf("invalid extra values %s, expected a JSON schema object: %w", string(rawExtraValues), err)
+	}
+	props.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{Schema: schema, Allows: true}
+	return nil
+}
+
+// parseEnum parses the JSON array argument of the fybrik enum marker
+func parseEnum(rawEnum markers.RawArguments) ([]apiext.JSON, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(rawEnum, &values); err != nil {
+		return nil, fmt.Errorf("invalid enum %s, expected a JSON array: %w", string(rawEnum), err)
+	}
+	enum := make([]apiext.JSON, 0, len(values))
+	for _, value := range values {
+		enum = append(enum, apiext.JSON{Raw: value})
+	}
+	return enum, nil
+}
+
+// checkEnumType checks that the enum values of a schema are of the schema type
+func checkEnumType(props *apiext.JSONSchemaProps) error {
+	for _, value := range props.Enum {
+		var decoded interface{}
+		if err := json.Unmarshal(value.Raw, &decoded); err != nil {
+			return err
+		}
+		matches := true
+		switch props.Type {
+		case "integer":
+			number, isNumber := decoded.(float64)
+			matches = isNumber && number == math.Trunc(number)
+		case "number":
+			_, matches = decoded.(float64)
+		case "string":
+			_, matches = decoded.(string)
+		case "boolean":
+			_, matches = decoded.(bool)
+		}
+		if !matches {
+			return fmt.Errorf("enum value %s is not of type %s", string(value.Raw), props.Type)
+		}
+	}
+	return nil
 }
 
 // typeToSchema creates a schema for the given AST type.
@@ -198,9 +504,12 @@ func typeToSchema(ctx *schemaContext, rawType ast.Expr) *apiext.JSONSchemaProps
 		props = typeToSchema(ctx, expr.X)
 	case *ast.StructType:
 		props = structToSchema(ctx, expr)
+	case *ast.InterfaceType:
+		props = interfaceToSchema(ctx, expr)
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		props = aliasedToSchema(ctx, ctx.pkg.TypesInfo.TypeOf(expr), expr)
 	default:
-		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unsupported AST kind %T", expr), rawType))
-		// NB(directxman12): we explicitly don't handle interfaces
+		ctx.pkg.AddError(loader.ErrFromNode(unsupportedTypeError(ctx, rawType), rawType))
 		return &apiext.JSONSchemaProps{}
 	}
 
@@ -211,6 +520,36 @@ func typeToSchema(ctx *schemaContext, rawType ast.Expr) *apiext.JSONSchemaProps
 	return props
 }
 
+// unsupportedTypeError returns an error about a type expression without a schema, such as a function type,
+// with the field or type it occurs in and a suggestion
+func unsupportedTypeError(ctx *schemaContext, rawType ast.Expr) error {
+	location := ctx.field
+	if location == Empty {
+		location = fmt.Sprintf("type %q", ctx.info.Name)
+	}
+	suggestion := "use a type that encoding/json serializes, or declare the schema of the field with the " + shapeMarker.Name + " marker"
+	switch rawType.(type) {
+	case *ast.FuncType, *ast.ChanType, *ast.Ellipsis:
+		suggestion = "encoding/json can't serialize functions and channels, exclude the field with the `json:\"-\"` tag"
+	}
+	return fmt.Errorf("unsupported type %s of %s (AST kind %T): %s", types.ExprString(rawType), location, rawType, suggestion)
+}
+
+// isUnserializable returns whether encoding/json fails to serialize values of the given type,
+// which are functions, channels and unsafe pointers that don't implement json.Marshaler
+func isUnserializable(typ types.Type) bool {
+	if typ == nil || implementsJSONMarshaler(typ) {
+		return false
+	}
+	switch underlying := typ.Underlying().(type) {
+	case *types.Signature, *types.Chan:
+		return true
+	case *types.Basic:
+		return underlying.Kind() == types.UnsafePointer
+	}
+	return false
+}
+
 // localNamedToSchema creates a schema (ref) for a *potentially* local type reference
 // (could be external from a dot-import).
 func localNamedToSchema(ctx *schemaContext, ident *ast.Ident) *apiext.JSONSchemaProps {
@@ -220,7 +559,7 @@ func localNamedToSchema(ctx *schemaContext, ident *ast.Ident) *apiext.JSONSchema
 		return &apiext.JSONSchemaProps{}
 	}
 	if basicInfo, isBasic := typeInfo.(*types.Basic); isBasic {
-		typ, format, err := builtinToType(basicInfo, ctx.allowDangerousTypes)
+		typ, format, err := builtinToType(basicInfo, ctx.allowDangerousTypes, ctx.intWidth)
 		if err != nil {
 			ctx.pkg.AddError(loader.ErrFromNode(err, ident))
 		}
@@ -229,10 +568,18 @@ func localNamedToSchema(ctx *schemaContext, ident *ast.Ident) *apiext.JSONSchema
 			Format: format,
 		}
 	}
+	namedInfo, isNamed := typeInfo.(*types.Named)
+	if !isNamed || namedInfo.TypeArgs().Len() > 0 {
+		// an alias of an unnamed type, such as `type StringMap = map[string]string`, or of an instantiation
+		return aliasedToSchema(ctx, typeInfo, ident)
+	}
 	// NB(directxman12): if there are dot imports, this might be an external reference,
 	// so use typechecking info to get the actual object
-	typeNameInfo := typeInfo.(*types.Named).Obj()
+	typeNameInfo := namedInfo.Obj()
 	pkg := typeNameInfo.Pkg()
+	if schema, isKnown := ctx.wellKnownTypeToSchema(typeNameInfo); isKnown {
+		return schema
+	}
 	pkgPath := loader.NonVendorPath(pkg.Path())
 	if pkg == ctx.pkg.Types {
 		pkgPath = Empty
@@ -246,6 +593,98 @@ func localNamedToSchema(ctx *schemaContext, ident *ast.Ident) *apiext.JSONSchema
 	}
 }
 
+// aliasedToSchema creates a schema for the type an alias stands for.  Aliases have no
+// definition of their own, so the structure of unnamed types is inlined while named types
+// are still referenced.
+func aliasedToSchema(ctx *schemaContext, typeInfo types.Type, node ast.Node) *apiext.JSONSchemaProps {
+	switch typ := typeInfo.(type) {
+	case *types.Basic:
+		typeName, format, err := builtinToType(typ, ctx.allowDangerousTypes, ctx.intWidth)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, node))
+		}
+		return &apiext.JSONSchemaProps{
+			Type:   typeName,
+			Format: format,
+		}
+	case *types.Named:
+		return aliasedNamedToSchema(ctx, typ, node)
+	case *types.Pointer:
+		return aliasedToSchema(ctx, typ.Elem(), node)
+	case *types.Slice:
+		if typ.Elem() == byteType {
+			return &apiext.JSONSchemaProps{
+				Type:   "string",
+				Format: "byte",
+			}
+		}
+		return &apiext.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiext.JSONSchemaPropsOrArray{Schema: aliasedElementToSchema(ctx, typ.Elem(), node)},
+		}
+	case *types.Array:
+		props := &apiext.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiext.JSONSchemaPropsOrArray{Schema: aliasedElementToSchema(ctx, typ.Elem(), node)},
+		}
+		setFixedLength(props, typ.Len())
+		return props
+	case *types.Map:
+		return aliasedMapToSchema(ctx, typ, node)
+	case *types.Interface:
+		// any is an alias of interface{}, whose values are arbitrary JSON values
+		if typ.Empty() {
+			return &apiext.JSONSchemaProps{}
+		}
+		ctx.pkg.AddError(loader.ErrFromNode(errUnsupportedInterface, node))
+		return &apiext.JSONSchemaProps{}
+	case *types.TypeParam:
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf(
+			"type parameter %s has no schema, use an instantiation of the generic type such as Wrapper[int]", typ.String()), node))
+		return &apiext.JSONSchemaProps{}
+	default:
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unsupported aliased type %s", typeInfo.String()), node))
+		return &apiext.JSONSchemaProps{}
+	}
+}
+
+// aliasedNamedToSchema returns the schema of a named type in an aliased type, which references the schema of the named type
+func aliasedNamedToSchema(ctx *schemaContext, typ *types.Named, node ast.Node) *apiext.JSONSchemaProps {
+	typeNameInfo := typ.Obj()
+	if typ.TypeArgs().Len() > 0 {
+		return instantiatedToSchema(ctx, typ, node)
+	}
+	if schema, isKnown := ctx.wellKnownTypeToSchema(typeNameInfo); isKnown {
+		return schema
+	}
+	pkgPath := loader.NonVendorPath(typeNameInfo.Pkg().Path())
+	if typeNameInfo.Pkg() == ctx.pkg.Types {
+		pkgPath = Empty
+	}
+	typeIdent := ctx.typeIdentFor(pkgPath, typeNameInfo.Name())
+	ctx.requestSchema(typeIdent)
+	link := ctx.schemaRequester.TypeRefLink(ctx.pkg, typeIdent)
+	return &apiext.JSONSchemaProps{
+		Ref: &link,
+	}
+}
+
+// aliasedMapToSchema returns the schema of a map type in an aliased type, whose keys must be strings
+func aliasedMapToSchema(ctx *schemaContext, typ *types.Map, node ast.Node) *apiext.JSONSchemaProps {
+	keyInfo, isBasic := typ.Key().Underlying().(*types.Basic)
+	if !isBasic || keyInfo.Info()&types.IsString == 0 {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("map keys must be strings, not %s", typ.Key().String()), node))
+		return &apiext.JSONSchemaProps{}
+	}
+	return &apiext.JSONSchemaProps{
+		Type: "object",
+		AdditionalProperties: &apiext.JSONSchemaPropsOrBool{
+			Schema: aliasedElementToSchema(ctx, typ.Elem(), node),
+			Allows: true,
+		},
+	}
+}
+
 // namedSchema creates a schema (ref) for an explicitly external type reference.
 func namedToSchema(ctx *schemaContext, named *ast.SelectorExpr) *apiext.JSONSchemaProps {
 	typeInfoRaw := ctx.pkg.TypesInfo.TypeOf(named)
@@ -253,8 +692,14 @@ func namedToSchema(ctx *schemaContext, named *ast.SelectorExpr) *apiext.JSONSche
 		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %v.%s", named.X, named.Sel.Name), named))
 		return &apiext.JSONSchemaProps{}
 	}
-	typeInfo := typeInfoRaw.(*types.Named)
+	typeInfo, isNamed := typeInfoRaw.(*types.Named)
+	if !isNamed || typeInfo.TypeArgs().Len() > 0 {
+		return aliasedToSchema(ctx, typeInfoRaw, named)
+	}
 	typeNameInfo := typeInfo.Obj()
+	if schema, isKnown := ctx.wellKnownTypeToSchema(typeNameInfo); isKnown {
+		return schema
+	}
 	nonVendorPath := loader.NonVendorPath(typeNameInfo.Pkg().Path())
 	typeIdent := ctx.typeIdentFor(nonVendorPath, typeNameInfo.Name())
 	ctx.requestSchema(typeIdent)
@@ -265,6 +710,23 @@ func namedToSchema(ctx *schemaContext, named *ast.SelectorExpr) *apiext.JSONSche
 	// NB(directxman12): we special-case things like resource.Quantity during the "collapse" phase.
 }
 
+// wellKnownTypeToSchema returns a copy of the inlined schema for the given named type,
+// if it is overridden or one of the wellKnownTypes.
+func (c *schemaContext) wellKnownTypeToSchema(typeNameInfo *types.TypeName) (*apiext.JSONSchemaProps, bool) {
+	if typeNameInfo.Pkg() == nil {
+		return nil, false
+	}
+	name := loader.NonVendorPath(typeNameInfo.Pkg().Path()) + "." + typeNameInfo.Name()
+	schema, isKnown := c.typeOverrides[name]
+	if !isKnown {
+		schema, isKnown = wellKnownTypes[name]
+	}
+	if !isKnown {
+		return nil, false
+	}
+	return schema.DeepCopy(), true
+}
+
 // arrayToSchema creates a schema for the items of the given array, dealing appropriately
 // with the special `[]byte` type (according to OpenAPI standards).
 func arrayToSchema(ctx *schemaContext, array *ast.ArrayType) *apiext.JSONSchemaProps {
@@ -278,12 +740,65 @@ func arrayToSchema(ctx *schemaContext, array *ast.ArrayType) *apiext.JSONSchemaP
 		}
 	}
 	// TODO(directxman12): backwards-compat would require access to markers from base info
-	items := typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), array.Elt)
+	items := nullableElementSchema(ctx, array.Elt, typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), array.Elt))
 
-	return &apiext.JSONSchemaProps{
+	props := &apiext.JSONSchemaProps{
 		Type:  "array",
 		Items: &apiext.JSONSchemaPropsOrArray{Schema: items},
 	}
+	// fixed-size arrays hold exactly as many items as their length
+	if arrayInfo, isArray := ctx.pkg.TypesInfo.TypeOf(array).(*types.Array); isArray {
+		setFixedLength(props, arrayInfo.Len())
+	}
+	return props
+}
+
+// nullableElementSchema permits null for the schema of a pointer element of a collection,
+// when nullable collections are enabled
+func nullableElementSchema(ctx *schemaContext, elt ast.Expr, schema *apiext.JSONSchemaProps) *apiext.JSONSchemaProps {
+	if _, isPointer := elt.(*ast.StarExpr); !isPointer || !ctx.nullableCollections {
+		return schema
+	}
+	nullable := nullableSchema(*schema)
+	return &nullable
+}
+
+// aliasedElementToSchema creates a schema for the element type of an aliased collection, like aliasedToSchema,
+// which permits null for a pointer element when nullable collections are enabled, like nullableElementSchema
+func aliasedElementToSchema(ctx *schemaContext, elem types.Type, node ast.Node) *apiext.JSONSchemaProps {
+	schema := aliasedToSchema(ctx, elem, node)
+	if _, isPointer := elem.(*types.Pointer); !isPointer || !ctx.nullableCollections {
+		return schema
+	}
+	nullable := nullableSchema(*schema)
+	return &nullable
+}
+
+// isCollectionField returns whether a field is a slice or a map, rather than a pointer to one
+func isCollectionField(rawType ast.Expr, fieldType types.Type) bool {
+	if _, isPointer := rawType.(*ast.StarExpr); isPointer {
+		return false
+	}
+	switch fieldType.Underlying().(type) {
+	case *types.Slice, *types.Map:
+		return true
+	}
+	return false
+}
+
+// nullableFieldSchema permits null for the schema of a field, keeping its annotations on the field
+func nullableFieldSchema(schema *apiext.JSONSchemaProps) *apiext.JSONSchemaProps {
+	value := *schema
+	value.Title, value.Description, value.Example = Empty, Empty, nil
+	nullable := nullableSchema(value)
+	nullable.Title, nullable.Description, nullable.Example = schema.Title, schema.Description, schema.Example
+	return &nullable
+}
+
+// setFixedLength bounds the number of items of an array schema to the given length
+func setFixedLength(props *apiext.JSONSchemaProps, length int64) {
+	props.MinItems = &length
+	props.MaxItems = &length
 }
 
 // mapToSchema creates a schema for items of the given map.  Key types must eventually resolve
@@ -317,11 +832,11 @@ func mapToSchema(ctx *schemaContext, mapType *ast.MapType) *apiext.JSONSchemaPro
 	case *ast.ArrayType:
 		valSchema = arrayToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
 	case *ast.StarExpr:
-		valSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
-	case *ast.MapType:
+		valSchema = nullableElementSchema(ctx, val, typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), val))
+	case *ast.MapType, *ast.StructType, *ast.InterfaceType, *ast.IndexExpr, *ast.IndexListExpr:
 		valSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
 	default:
-		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("not a supported map value type: %T", mapType.Value), mapType.Value))
+		ctx.pkg.AddError(loader.ErrFromNode(unsupportedTypeError(ctx, mapType.Value), mapType.Value))
 		return &apiext.JSONSchemaProps{}
 	}
 
@@ -334,8 +849,29 @@ func mapToSchema(ctx *schemaContext, mapType *ast.MapType) *apiext.JSONSchemaPro
 	}
 }
 
+var errUnsupportedInterface = errors.New(
+	"unsupported interface type, add the oneOf marker to the interface type or the field, or the shape marker to the field")
+
+// interfaceToSchema creates a schema for the given interface, whose values are one of the types
+// listed by the oneOf marker.  Interfaces without the marker can't be traversed.
+func interfaceToSchema(ctx *schemaContext, interfaceType *ast.InterfaceType) *apiext.JSONSchemaProps {
+	iface, _ := ctx.pkg.TypesInfo.TypeOf(interfaceType).(*types.Interface)
+	variants, isSet := ctx.info.Markers.Get(oneOfMarker.Name).(OneOfTypes)
+	isDeclared := ctx.info.RawSpec != nil && ctx.info.RawSpec.Type == interfaceType
+	if !isDeclared || !isSet {
+		// Note: the values of empty interfaces, such as interface{} and any, are arbitrary JSON values
+		if iface != nil && iface.Empty() {
+			return &apiext.JSONSchemaProps{}
+		}
+		ctx.pkg.AddError(loader.ErrFromNode(errUnsupportedInterface, interfaceType))
+		return &apiext.JSONSchemaProps{}
+	}
+	return oneOfToSchema(ctx, variants, iface, ctx.info.Name, interfaceType)
+}
+
 // structToSchema creates a schema for the given struct.  Embedded fields are placed in AllOf,
-// and can be flattened later with a Flattener.
+// and can be flattened later with a Flattener.  AllOf keeps the declaration order of the embedded
+// fields, which the processing of the documents must preserve for the output to be stable.
 //
 //nolint:gocyclo
 func structToSchema(ctx *schemaContext, structType *ast.StructType) *apiext.JSONSchemaProps {
@@ -344,12 +880,36 @@ func structToSchema(ctx *schemaContext, structType *ast.StructType) *apiext.JSON
 		Properties: make(map[string]apiext.JSONSchemaProps),
 	}
 
-	if ctx.info.RawSpec.Type != structType {
-		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("encountered non-top-level struct (possibly embedded), those aren't allowed"), structType))
+	// empty structs, such as the struct{} values of sets, have no fields to describe, even if they are anonymous
+	if structType.Fields == nil || len(structType.Fields.List) == 0 {
+		if ctx.emptyStruct == closedEmptyStruct {
+			props.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{Allows: false}
+		}
+		return props
+	}
+
+	if ctx.info.RawSpec == nil || ctx.info.RawSpec.Type != structType {
+		// anonymous structs, such as the types of fields declared inline, have no type information of their own
+		info := anonymousInfo(ctx, structType)
+		if err := setKeyword(props, anonymousKeyword, info.Name); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, structType))
+		}
+		fieldsToSchema(ctx.ForInfo(info), props, nil)
 		return props
 	}
 
-	for _, field := range ctx.info.Fields {
+	fieldsToSchema(ctx, props, nil)
+	return props
+}
+
+// fieldsToSchema adds the fields of the struct type of ctx.info to the given object schema.  The types of
+// instantiated generic structs are given in fieldTypes, in the order of the fields, since the AST of the
+// fields has the type parameters in place of the type arguments.
+//
+//nolint:gocyclo
+func fieldsToSchema(ctx *schemaContext, props *apiext.JSONSchemaProps, fieldTypes []types.Type) {
+	for i := range ctx.info.Fields {
+		field := &ctx.info.Fields[i]
 		jsonTag, hasTag := field.Tag.Lookup("json")
 		if !hasTag {
 			// if the field doesn't have a JSON tag, it doesn't belong in output (and shouldn't exist in a serialized type)
@@ -363,50 +923,36 @@ func structToSchema(ctx *schemaContext, structType *ast.StructType) *apiext.JSON
 			continue
 		}
 
-		inline := false
-		omitEmpty := false
-		for _, opt := range jsonOpts[1:] {
-			switch opt {
-			case "inline":
-				inline = true
-			case "omitempty":
-				omitEmpty = true
-			}
-		}
+		inline, omitEmpty := jsonTagOptions(jsonOpts[1:])
 		fieldName := jsonOpts[0]
 		inline = inline || fieldName == Empty // anonymous fields are inline fields in YAML/JSON
 
-		// if no default required mode is set, default to required
-		defaultMode := Required
-		if ctx.PackageMarkers.Get("kubebuilder:validation:Optional") != nil {
-			defaultMode = Optional
+		fieldType := ctx.pkg.TypesInfo.TypeOf(field.RawField.Type)
+		if fieldTypes != nil {
+			fieldType = fieldTypes[i]
+		}
+		if isUnserializable(fieldType) {
+			ctx.warn(field.RawField, "field %q of type %q has type %s, which encoding/json can't serialize, and is skipped; "+
+				"exclude it with the `json:\"-\"` tag", fieldName, ctx.info.Name, fieldType.String())
+			continue
 		}
 
-		switch defaultMode {
-		// if this package isn't set to optional default...
-		case Required:
-			// ...everything that's not inline, omitempty, or explicitly optional is required
-			if !inline && !omitEmpty && field.Markers.Get("kubebuilder:validation:Optional") == nil && field.Markers.Get("optional") == nil {
-				props.Required = append(props.Required, fieldName)
-			}
-
-		// if this package isn't set to required default...
-		case Optional:
-			// ...everything that isn't explicitly required is optional
-			if field.Markers.Get("kubebuilder:validation:Required") != nil {
-				props.Required = append(props.Required, fieldName)
-			}
+		// Note: encoding/json omits empty values of omitempty fields, which a required field rejects
+		if omitEmpty && field.Markers.Get("kubebuilder:validation:Required") != nil {
+			ctx.warn(field.RawField, "field %q of type %q is marked as required but has the omitempty option", fieldName, ctx.info.Name)
+		}
+		if !inline && isRequiredField(ctx, field, omitEmpty) {
+			props.Required = append(props.Required, fieldName)
 		}
 
-		var propSchema *apiext.JSONSchemaProps
-		if field.Markers.Get(crdmarkers.SchemalessName) != nil {
-			propSchema = &apiext.JSONSchemaProps{}
-		} else {
-			propSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), field.RawField.Type)
+		var aliasedType types.Type
+		if fieldTypes != nil {
+			aliasedType = fieldTypes[i]
 		}
-		propSchema.Description = field.Doc
+		propSchema := fieldTypeToSchema(ctx, field, aliasedType)
+		decorateFieldSchema(ctx, field, propSchema)
 
-		applyMarkers(ctx, field.Markers, propSchema, field.RawField)
+		propSchema = nullableField(ctx, field, fieldType, propSchema, inline, omitEmpty)
 
 		if inline {
 			props.AllOf = append(props.AllOf, *propSchema)
@@ -415,14 +961,165 @@ func structToSchema(ctx *schemaContext, structType *ast.StructType) *apiext.JSON
 
 		props.Properties[fieldName] = *propSchema
 	}
+}
 
-	return props
+// nullableField returns the schema of a field that also permits null, if the nullable options apply to the field
+func nullableField(ctx *schemaContext, field *markers.FieldInfo, fieldType types.Type, propSchema *apiext.JSONSchemaProps,
+	inline, omitEmpty bool) *apiext.JSONSchemaProps {
+	if ctx.nullableOmitEmpty && omitEmpty && isCollectionField(field.RawField.Type, fieldType) {
+		propSchema = nullableFieldSchema(propSchema)
+	}
+	if _, isPointer := field.RawField.Type.(*ast.StarExpr); ctx.nullablePointers && isPointer && !inline {
+		propSchema = nullableFieldSchema(propSchema)
+	}
+	return propSchema
+}
+
+// jsonTagOptions returns whether the options of a json tag, after the name, include inline and omitempty
+func jsonTagOptions(opts []string) (inline, omitEmpty bool) {
+	for _, opt := range opts {
+		switch opt {
+		case "inline":
+			inline = true
+		case "omitempty":
+			omitEmpty = true
+		}
+	}
+	return inline, omitEmpty
+}
+
+// isRequiredField returns whether a field is required, according to its markers, its omitempty option,
+// and the default of its package
+func isRequiredField(ctx *schemaContext, field *markers.FieldInfo, omitEmpty bool) bool {
+	// if no default required mode is set, default to required
+	defaultMode := Required
+	if ctx.PackageMarkers.Get("kubebuilder:validation:Optional") != nil {
+		defaultMode = Optional
+	}
+
+	switch defaultMode {
+	// if this package isn't set to optional default...
+	case Required:
+		// ...everything that's not inline, omitempty, or explicitly optional is required
+		// Note: an explicit required marker takes precedence over omitempty
+		return field.Markers.Get("kubebuilder:validation:Required") != nil ||
+			!omitEmpty && field.Markers.Get("kubebuilder:validation:Optional") == nil && field.Markers.Get("optional") == nil
+
+	// if this package isn't set to required default...
+	case Optional:
+		// ...everything that isn't explicitly required is optional
+		return field.Markers.Get("kubebuilder:validation:Required") != nil
+	}
+	return false
+}
+
+// fieldTypeToSchema returns the schema of the type of a field, or the schema that its markers declare.
+// The aliased type is the type of the field in an instantiation of a generic type, or nil.
+func fieldTypeToSchema(ctx *schemaContext, field *markers.FieldInfo, aliasedType types.Type) *apiext.JSONSchemaProps {
+	if field.Markers.Get(crdmarkers.SchemalessName) != nil {
+		return &apiext.JSONSchemaProps{}
+	}
+	if rawShape, isSet := field.Markers.Get(shapeMarker.Name).(markers.RawArguments); isSet {
+		// Note: the types of interface fields can't be traversed, so their schema is declared instead
+		propSchema := &apiext.JSONSchemaProps{}
+		if err := json.Unmarshal(rawShape, propSchema); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(
+				fmt.Errorf("invalid shape %s, expected a JSON schema object: %w", string(rawShape), err), field.RawField))
+		}
+		return propSchema
+	}
+	if variants, isSet := field.Markers.Get(oneOfFieldMarker.Name).(OneOfTypes); isSet {
+		return interfaceFieldToSchema(ctx, variants, field.RawField)
+	}
+	if objName, isSet := field.Markers.Get(objectFieldMarker.Name).(FieldObjName); isSet {
+		if aliasedType != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(
+				fmt.Errorf("the %s marker isn't supported in generic types", objectFieldMarker.Name), field.RawField))
+			return &apiext.JSONSchemaProps{}
+		}
+		return fieldObjectToSchema(ctx, string(objName), field.RawField.Type)
+	}
+	if aliasedType != nil {
+		return aliasedToSchema(ctx, aliasedType, field.RawField)
+	}
+	fieldCtx := ctx.ForInfo(&markers.TypeInfo{})
+	fieldCtx.anonymousName = ctx.info.Name + field.Name
+	fieldCtx.field = fmt.Sprintf("field %q of type %q", field.Name, ctx.info.Name)
+	return typeToSchema(fieldCtx, field.RawField.Type)
+}
+
+// decorateFieldSchema sets the description, the deprecation and the example of the schema of a field,
+// and applies the markers of the field
+func decorateFieldSchema(ctx *schemaContext, field *markers.FieldInfo, propSchema *apiext.JSONSchemaProps) {
+	propSchema.Description = field.Doc
+	if ctx.harvestDeprecations && isDeprecated(field.RawField.Doc) {
+		if err := setKeyword(propSchema, "deprecated", true); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, field.RawField))
+		}
+	}
+	if ctx.exampleTag != Empty {
+		if example, hasExample := field.Tag.Lookup(ctx.exampleTag); hasExample {
+			propSchema.Example = exampleToJSON(example)
+		}
+	}
+
+	applyMarkers(ctx, field.Markers, propSchema, field.RawField)
+
+	if field.Markers.Get(secretMarker.Name) != nil || field.Tag.Get("secret") == "true" {
+		if err := setSecret(propSchema); err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, field.RawField))
+		}
+	}
+}
+
+// setSecret marks the schema of a credential field as writeOnly, with the password format for strings,
+// and removes its example
+func setSecret(props *apiext.JSONSchemaProps) error {
+	if props.Type == "string" {
+		props.Format = "password"
+	}
+	props.Example = nil
+	return setKeyword(props, "writeOnly", true)
+}
+
+// exampleToJSON parses the example of a field as JSON, falling back to a string
+func exampleToJSON(example string) *apiext.JSON {
+	if json.Valid([]byte(example)) {
+		return &apiext.JSON{Raw: []byte(example)}
+	}
+	raw, _ := json.Marshal(example)
+	return &apiext.JSON{Raw: raw}
+}
+
+// fieldObjectToSchema creates a schema (ref) for a field whose named type is split into a document of its own
+func fieldObjectToSchema(ctx *schemaContext, name string, rawType ast.Expr) *apiext.JSONSchemaProps {
+	if star, isStar := rawType.(*ast.StarExpr); isStar {
+		rawType = star.X
+	}
+	namedInfo, isNamed := ctx.pkg.TypesInfo.TypeOf(rawType).(*types.Named)
+	if !isNamed || namedInfo.Obj().Pkg() == nil {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("the %s marker requires a field of a named type", objectFieldMarker.Name), rawType))
+		return &apiext.JSONSchemaProps{}
+	}
+	pkgPath := loader.NonVendorPath(namedInfo.Obj().Pkg().Path())
+	if namedInfo.Obj().Pkg() == ctx.pkg.Types {
+		pkgPath = Empty
+	}
+	typeIdent := ctx.typeIdentFor(pkgPath, namedInfo.Obj().Name())
+	link, err := ctx.schemaRequester.ObjectRefLink(name, typeIdent)
+	if err != nil {
+		ctx.pkg.AddError(loader.ErrFromNode(err, rawType))
+		return &apiext.JSONSchemaProps{}
+	}
+	return &apiext.JSONSchemaProps{
+		Ref: &link,
+	}
 }
 
 // builtinToType converts builtin basic types to their equivalent JSON schema form.
 // It *only* handles types allowed by the kubernetes API standards. Floats are not
 // allowed unless allowDangerousTypes is true
-func builtinToType(basic *types.Basic, allowDangerousTypes bool) (typ, format string, err error) {
+func builtinToType(basic *types.Basic, allowDangerousTypes bool, intWidth int) (typ, format string, err error) {
 	// NB(directxman12): formats from OpenAPI v3 are slightly different from those defined
 	// in JSONSchema.  This uses the OpenAPI v3 ones, since they're useful for bounding our
 	// non-string types.
@@ -446,7 +1143,19 @@ func builtinToType(basic *types.Basic, allowDangerousTypes bool) (typ, format st
 		return Empty, Empty, fmt.Errorf("unsupported type %q", basic.String())
 	}
 
-	switch basic.Kind() {
+	kind := basic.Kind()
+	// Note: int and uint are as wide as the platform, so they're assumed to be intWidth bits wide for a stable format
+	switch {
+	case kind == types.Int && intWidth == 32:
+		kind = types.Int32
+	case kind == types.Int:
+		kind = types.Int64
+	case kind == types.Uint && intWidth == 32:
+		kind = types.Uint32
+	case kind == types.Uint:
+		kind = types.Uint64
+	}
+	switch kind {
 	case types.Int32, types.Uint32:
 		format = "int32"
 	case types.Int64, types.Uint64:
@@ -1,14 +1,33 @@
 package schemas
 
 import (
+	"bytes"
 	"encoding/json"
+	"go/ast"
+	"io"
+	"math/big"
+	"net"
+	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/resolver"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/markers"
 
 	fybrikobject "fybrik.io/json-schema-generator/testPkgs/fybrikobject"
 	schemapkg "fybrik.io/json-schema-generator/testPkgs/schemapkg"
+	validationpkg "fybrik.io/json-schema-generator/testPkgs/validationpkg"
 )
 
 func TestValidApp(t *testing.T) {
@@ -97,3 +116,2397 @@ func createInvalidResource() ([]byte, error) {
 	buf, err := json.Marshal(crd)
 	return buf, err
 }
+
+// validationCase is a JSON resource and whether it is expected to be valid
+type validationCase struct {
+	name     string
+	resource string
+	valid    bool
+}
+
+// validateDefinition validates JSON resources against a definition of a generated schema document
+func validateDefinition(t *testing.T, document, definition string, tests []validationCase) {
+	t.Helper()
+	schemaPath, err := filepath.Abs(filepath.Join("../../testdata/schema", document))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	schemaLoader := gojsonschema.NewReferenceLoader("file://" + schemaPath + "#/definitions/" + definition)
+	for _, tt := range tests {
+		result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewStringLoader(tt.resource))
+		if err != nil {
+			t.Fatalf("could not validate resource against the provided schema, err: %v\n", err)
+		}
+		if result.Valid() != tt.valid {
+			t.Errorf("%s: expected valid=%v, got errors %v", tt.name, tt.valid, result.Errors())
+		}
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "TimeFormats", []validationCase{
+		{"valid", `{"dateTime": "2021-01-02T15:04:05Z", "date": "2021-01-02", "unix": 1609599845}`, true},
+		{"invalid date-time", `{"dateTime": "yesterday", "date": "2021-01-02", "unix": 1609599845}`, false},
+		{"date as date-time", `{"dateTime": "2021-01-02T15:04:05Z", "date": "2021-01-02T15:04:05Z", "unix": 1609599845}`, false},
+		{"unix as string", `{"dateTime": "2021-01-02T15:04:05Z", "date": "2021-01-02", "unix": "1609599845"}`, false},
+	})
+}
+
+func TestNullablePointers(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Schedule", []validationCase{
+		{"dates", `{"start": "2021-01-02T15:04:05Z", "end": "2021-01-03"}`, true},
+		{"null", `{"start": null}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{NullablePointers: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/Schedule", []validationCase{
+		{"dates", `{"start": "2021-01-02T15:04:05Z", "end": "2021-01-03"}`, true},
+		{"nulls", `{"start": null, "end": null}`, true},
+		{"invalid date-time", `{"start": "yesterday"}`, false},
+		{"date-time as date", `{"start": null, "end": "2021-01-02T15:04:05Z"}`, false},
+	})
+}
+
+func TestNamedSliceBounds(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Tagged", []validationCase{
+		{"within bounds", `{"tags": ["a", "b"]}`, true},
+		{"under length", `{"tags": []}`, false},
+		{"over length", `{"tags": ["a", "b", "c", "d"]}`, false},
+	})
+}
+
+func TestNamedCompositeTypes(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Tags", []validationCase{
+		{"slice", `["a"]`, true},
+		{"object", `{"a": "b"}`, false},
+		{"over length", `["a", "b", "c", "d"]`, false},
+	})
+	validateDefinition(t, "validationpkg.json", "Annotations", []validationCase{
+		{"map", `{"a": "b"}`, true},
+		{"array", `["a"]`, false},
+		{"non-string value", `{"a": 1}`, false},
+		{"too many properties", `{"a": "b", "c": "d", "e": "f"}`, false},
+	})
+}
+
+func TestConstrainedNamedMap(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Configured", []validationCase{
+		{"non-empty config", `{"config": {"a": "b"}, "overrides": {"x": {"c": "d"}}}`, true},
+		{"empty config", `{"config": {}}`, false},
+		{"empty override", `{"config": {"a": "b"}, "overrides": {"x": {}}}`, false},
+		{"non-string value", `{"config": {"a": 1}}`, false},
+	})
+}
+
+func TestFreeFormFields(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "FreeForm", []validationCase{
+		{"scalar value", `{"value": 1}`, true},
+		{"null value", `{"value": null}`, true},
+		{"values of any type", `{"value": {"a": [1, "b"]}, "legacy": "x", "items": [1, "a", null, {}],
+			"settings": {"a": true, "b": [1]}, "nested": {"a": {"b": 1}}}`, true},
+		{"missing value", `{"legacy": "x"}`, false},
+		{"items not an array", `{"value": 1, "items": {"a": 1}}`, false},
+		{"settings not an object", `{"value": 1, "settings": [1]}`, false},
+	})
+}
+
+func TestByteMapValues(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Blobs", []validationCase{
+		{"base64 values", `{"files": {"a": "aGVsbG8="}}`, true},
+		{"integer array values", `{"files": {"a": [104, 105]}}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/validationpkg")
+	files := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Blobs"].Properties["files"]
+	if files.AdditionalProperties == nil || files.AdditionalProperties.Schema == nil {
+		t.Fatalf("expected a value schema, got %+v", files)
+	}
+	if value := files.AdditionalProperties.Schema; value.Type != "string" || value.Format != "byte" {
+		t.Errorf("unexpected []byte value schema %+v", value)
+	}
+}
+
+func TestFixedLengthArray(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Point", []validationCase{
+		{"exact length", `{"coordinates": [1, 2, 3]}`, true},
+		{"too short", `{"coordinates": [1, 2]}`, false},
+		{"too long", `{"coordinates": [1, 2, 3, 4]}`, false},
+	})
+}
+
+func TestNullableCollections(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "PointerItems", []validationCase{
+		{"points", `{"points": [{"coordinates": [1, 2, 3]}], "byName": {"a": {"coordinates": [1, 2, 3]}}}`, true},
+		{"null item", `{"points": [null], "byName": {}}`, false},
+	})
+	validateDefinition(t, "validationpkg.json", "AliasedPointerItems", []validationCase{
+		{"values", `{"byName": {"a": {"coordinates": [1, 2, 3]}}, "boxed": {"value": [{"coordinates": [1, 2, 3]}]}}`, true},
+		{"null value", `{"byName": {"a": null}}`, false},
+		{"null item", `{"byName": {}, "boxed": {"value": [null]}}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{NullableCollections: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/PointerItems", []validationCase{
+		{"null item", `{"points": [{"coordinates": [1, 2, 3]}, null], "byName": {}}`, true},
+		{"null value", `{"points": [], "byName": {"a": null}}`, true},
+		{"invalid item", `{"points": [{"coordinates": [1, 2]}], "byName": {}}`, false},
+	})
+
+	// the types of aliases and generic types are traversed like the pointers of fields
+	validateInMemory(t, documents, "validationpkg.json#/definitions/AliasedPointerItems", []validationCase{
+		{"null value", `{"byName": {"a": null, "b": {"coordinates": [1, 2, 3]}}}`, true},
+		{"null item", `{"byName": {}, "boxed": {"value": [null]}}`, true},
+		{"invalid value", `{"byName": {"a": {"coordinates": [1, 2]}}}`, false},
+	})
+}
+
+func TestNullableOmitEmpty(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "OptionalCollections", []validationCase{
+		{"empty", `{"names": [], "aliases": [], "labels": {}}`, true},
+		{"null omitempty", `{"names": [], "aliases": null}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{NullableOmitEmpty: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/OptionalCollections", []validationCase{
+		{"empty", `{"names": [], "aliases": [], "labels": {}, "tags": ["a"]}`, true},
+		{"null omitempty", `{"names": [], "aliases": null, "labels": null, "tags": null}`, true},
+		{"null non-omitempty", `{"names": null}`, false},
+		{"invalid omitempty", `{"names": [], "aliases": [1], "tags": []}`, false},
+	})
+	properties := unmarshalDocument(t, documents, "validationpkg.json").Definitions["OptionalCollections"].Properties
+	if names := properties["names"]; names.Type != "array" || names.Description != "The names of the items" {
+		t.Errorf("unexpected non-omitempty schema %+v", names)
+	}
+	if override := properties["override"]; override.Type != "array" {
+		t.Errorf("unexpected pointer schema %+v", override)
+	}
+}
+
+func TestGroupDocument(t *testing.T) {
+	for _, document := range []string{"groupa.json", "groupb.json"} {
+		if _, err := os.Stat(filepath.Join("../../testdata/schema", document)); !os.IsNotExist(err) {
+			t.Errorf("unexpected document %s for a grouped package", document)
+		}
+	}
+	validateDefinition(t, "group.fybrik.io.json", "GroupTypeA", []validationCase{
+		{"valid", `{"fieldB": {"name": "b"}}`, true},
+		{"invalid referenced type", `{"fieldB": {"name": ""}}`, false},
+	})
+}
+
+func TestVersionedDocuments(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/versionedpkg/v1", "../../testPkgs/versionedpkg/legacy")
+	names := []string{}
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// the documents of the unmarked v1 package keep their names
+	expected := []string{"versioned.fybrik.io.json", "versioned.fybrik.io.v1alpha1.json", "widget.json"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected documents %v", names)
+	}
+
+	for _, location := range []string{"versioned.fybrik.io.json#/definitions/Widget", "widget.json"} {
+		validateInMemory(t, documents, location, []validationCase{
+			{"valid", `{"spec": {"size": 1, "legacy": {"count": 1}}}`, true},
+			{"invalid other version", `{"spec": {"size": 1, "legacy": {"count": 0}}}`, false},
+		})
+	}
+}
+
+func TestRequiredOmitEmpty(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "RequiredOmitEmpty", []validationCase{
+		{"required only", `{"required": "x"}`, true},
+		{"missing required", `{"optional": "y"}`, false},
+	})
+}
+
+func TestRequiredOmitEmptyWarning(t *testing.T) {
+	var warnings bytes.Buffer
+	generateInMemory(t, Generator{Warnings: &warnings}, LoadOptions{}, "../../testPkgs/validationpkg")
+	expected := `required_types.go:5:2: warning: field "required" of type "RequiredOmitEmpty" ` +
+		`is marked as required but has the omitempty option`
+	if !strings.Contains(warnings.String(), expected) {
+		t.Errorf("expected warning %q, got:\n%s", expected, warnings.String())
+	}
+	if strings.Count(warnings.String(), "warning:") != 1 {
+		t.Errorf("unexpected warnings:\n%s", warnings.String())
+	}
+}
+
+func TestRefAlias(t *testing.T) {
+	validateDefinition(t, "external.json", "fybrik.io~01json-schema-generator~01testPkgs~01oldpkg~00Type2", []validationCase{
+		{"valid", `{"type2f1": true}`, true},
+		{"invalid", `{"type2f1": "true"}`, false},
+	})
+}
+
+func TestRefAliasPruned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ref_aliases.json")
+	aliases := `{"fybrik.io/json-schema-generator/testPkgs/oldpkg.Unused": "fybrik.io/json-schema-generator/testPkgs/schemapkg.UnusedType"}`
+	if err := os.WriteFile(path, []byte(aliases), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := generateWithErrors(t, Generator{RefAliases: path}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	_, _, err = generateWithErrors(t, Generator{RefAliases: path, PruneUnreferenced: true}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if err == nil || !strings.Contains(err.Error(), "has no definition of fybrik.io/json-schema-generator/testPkgs/schemapkg.UnusedType") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMapKeyValidation(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "KeyValidation", []validationCase{
+		{"valid", `{"labels": {"app": "x"}, "colors": {"red": 1}}`, true},
+		{"over-long key", `{"labels": {"application": "x"}, "colors": {}}`, false},
+		{"key not matching pattern", `{"labels": {"App": "x"}, "colors": {}}`, false},
+		{"key not in enum", `{"labels": {}, "colors": {"blue": 1}}`, false},
+	})
+}
+
+func TestMapKeyPattern(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "PatternKeys", []validationCase{
+		{"matching keys", `{"extensions": {"x-a": 1, "x-b": 2}}`, true},
+		{"no keys", `{"extensions": {}}`, true},
+		{"key not matching pattern", `{"extensions": {"x-a": 1, "y": 2}}`, false},
+		{"value of wrong type", `{"extensions": {"x-a": "one"}}`, false},
+	})
+	extensions := loadDocument(t, "validationpkg.json").Definitions["PatternKeys"].Properties["extensions"]
+	if extensions.AdditionalProperties == nil || extensions.AdditionalProperties.Allows || extensions.AdditionalProperties.Schema != nil {
+		t.Errorf("unexpected additionalProperties %+v", extensions.AdditionalProperties)
+	}
+	if _, exists := extensions.PatternProperties["^x-[a-z]+$"]; !exists {
+		t.Errorf("missing patternProperties of the key pattern in %+v", extensions.PatternProperties)
+	}
+}
+
+func TestExactlyOneOf(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Source", []validationCase{
+		{"file", `{"file": "a", "format": "csv"}`, true},
+		{"url", `{"url": "b"}`, true},
+		{"none", `{"format": "csv"}`, false},
+		{"two", `{"file": "a", "url": "b"}`, false},
+		{"all", `{"file": "a", "url": "b", "inline": "c"}`, false},
+	})
+
+	schema := &apiext.JSONSchemaProps{Type: "object", Properties: map[string]apiext.JSONSchemaProps{"a": {}, "b": {}}}
+	if err := (ExactlyOneOf{"a", "c"}).ApplyToSchema(schema); err == nil || !strings.Contains(err.Error(), `unknown property "c"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSharedDefinition(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/consumerpkg", "../../testPkgs/sharedpkg")
+	common := unmarshalDocument(t, documents, "common.json")
+	labelsRef := common.Definitions["Labels"].AdditionalProperties.Schema.Ref
+	if labelsRef == nil || *labelsRef != "sharedpkg.json#/definitions/LabelValue" {
+		t.Errorf("unexpected reference from the shared definition %v", labelsRef)
+	}
+	for _, ref := range []struct {
+		document, definition string
+	}{{"sharedpkg.json", "Owner"}, {"consumerpkg.json", "Consumer"}} {
+		labels := unmarshalDocument(t, documents, ref.document).Definitions[ref.definition].Properties["labels"]
+		if labels.Ref == nil || *labels.Ref != "common.json#/definitions/Labels" {
+			t.Errorf("%s: unexpected reference to the shared definition %v", ref.document, labels.Ref)
+		}
+	}
+	if _, exists := unmarshalDocument(t, documents, "sharedpkg.json").Definitions["Labels"]; exists {
+		t.Error("unexpected shared definition in the document of its package")
+	}
+
+	validateInMemory(t, documents, "consumerpkg.json#/definitions/Consumer", []validationCase{
+		{"valid", `{"owner": {"name": "a", "labels": {"a": "b"}}, "labels": {"c": "d"}}`, true},
+		{"long label", `{"owner": {"name": "a", "labels": {}}, "labels": {"c": "123456789"}}`, false},
+	})
+}
+
+func TestInterfaceShape(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Shaped", []validationCase{
+		{"valid", `{"name": "a", "size": 1}`, true},
+		{"missing property of the shape", `{"size": 1}`, false},
+		{"property of the shape of wrong type", `{"name": 1, "size": 1}`, false},
+	})
+}
+
+func TestNestedDialects(t *testing.T) {
+	documents := generateInMemory(t, Generator{RecursiveRefs: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	for name := range documents {
+		document := unmarshalDocument(t, documents, name)
+		if document.Schema != draft201909 {
+			t.Errorf("document %s: unexpected $schema %s", name, document.Schema)
+		}
+		document.Schema = Empty
+		walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+			if subschema.Schema != Empty {
+				t.Errorf("document %s: unexpected nested $schema %s", name, subschema.Schema)
+			}
+		})
+	}
+	metadata := unmarshalDocument(t, documents, "validationpkg.json").Definitions["DialectShaped"].Properties["metadata"]
+	if metadata.Type != "object" {
+		t.Errorf("unexpected shape %+v", metadata)
+	}
+}
+
+func TestFormatAssertion(t *testing.T) {
+	baseURI := "https://fybrik.io/schemas"
+	documents := generateInMemory(t, Generator{ExternalBaseURI: baseURI, FormatAssertion: true}, LoadOptions{},
+		"../../testPkgs/validationpkg")
+	validateInMemory(t, documents, baseURI+"/validationpkg.json#/definitions/Contact", []validationCase{
+		{"email", `{"email": "a@fybrik.io"}`, true},
+		{"invalid email", `{"email": "fybrik.io"}`, false},
+	})
+
+	for _, generator := range []Generator{{FormatAssertion: true}, {ExternalBaseURI: baseURI, FormatAssertion: true, RecursiveRefs: true}} {
+		_, _, err := generateWithErrors(t, generator, LoadOptions{}, "../../testPkgs/externalpkg")
+		if err == nil || !strings.Contains(err.Error(), "format assertion requires") {
+			t.Errorf("%+v: unexpected error %v", generator, err)
+		}
+	}
+}
+
+func TestPatternProperties(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "PatternValues", []validationCase{
+		{"valid", `{"annotations": {"x-a": "abc", "n-a": "123", "other": "any value"}}`, true},
+		{"invalid value of first pattern", `{"annotations": {"x-a": "abcd"}}`, false},
+		{"invalid value of second pattern", `{"annotations": {"n-a": "abc"}}`, false},
+		{"invalid value of other keys", `{"annotations": {"other": 1}}`, false},
+	})
+}
+
+func TestAliasedContainers(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Aliased", []validationCase{
+		{"valid", `{"labels": {"app": "x"}, "levels": [1, 2]}`, true},
+		{"map value of wrong type", `{"labels": {"app": 1}, "levels": []}`, false},
+		{"slice item of wrong type", `{"labels": {}, "levels": ["high"]}`, false},
+		{"map instead of slice", `{"labels": {}, "levels": {"a": 1}}`, false},
+		{"aliased instantiation", `{"labels": {}, "levels": [], "box": {"value": 1}}`, true},
+		{"aliased instantiation of wrong type", `{"labels": {}, "levels": [], "box": {"value": "1"}}`, false},
+		{"map of instantiations", `{"labels": {}, "levels": [], "boxes": {"a": {"value": 1, "label": "x"}}}`, true},
+		{"invalid map value of instantiation", `{"labels": {}, "levels": [], "boxes": {"a": {"label": ""}}}`, false},
+	})
+	// Note: instantiations have no definitions of their own, so an alias of an instantiation inlines its structure
+	aliased := loadDocument(t, "validationpkg.json").Definitions["Aliased"]
+	for _, box := range []apiext.JSONSchemaProps{aliased.Properties["box"], *aliased.Properties["boxes"].AdditionalProperties.Schema} {
+		if box.Ref != nil || box.Properties["value"].Type != "integer" {
+			t.Errorf("expected the inlined structure of Wrapper[int], got %+v", box)
+		}
+	}
+}
+
+// memoryDocument is an in-memory io.WriteCloser for GenerateTo
+type memoryDocument struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (d *memoryDocument) Close() error {
+	d.closed = true
+	return nil
+}
+
+// generateInMemory generates the documents of the given roots with GenerateTo
+func generateInMemory(t *testing.T, g Generator, options LoadOptions, roots ...string) map[string]*memoryDocument {
+	t.Helper()
+	documents, _, err := generateWithErrors(t, g, options, roots...)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	return documents
+}
+
+// generateWithErrors generates the documents of the given roots with GenerateTo like generateInMemory, but returns
+// the error of the generation and the runtime, whose roots hold the errors of the packages, instead of failing
+func generateWithErrors(t *testing.T, g Generator, options LoadOptions, roots ...string) (map[string]*memoryDocument,
+	*genall.Runtime, error) {
+	t.Helper()
+	var generators genall.Generators
+	var generator genall.Generator = &g
+	generators = append(generators, &generator)
+	runtime, err := ForRoots(generators, options, roots...)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+
+	documents := make(map[string]*memoryDocument)
+	err = g.GenerateTo(&runtime.GenerationContext, func(name string) (io.WriteCloser, error) {
+		documents[name] = &memoryDocument{}
+		return documents[name], nil
+	})
+	return documents, runtime, err
+}
+
+// unmarshalDocument parses a document generated in memory
+func unmarshalDocument(t *testing.T, documents map[string]*memoryDocument, name string) *apiext.JSONSchemaProps {
+	t.Helper()
+	document, exists := documents[name]
+	if !exists {
+		t.Fatalf("missing document %s", name)
+	}
+	schema := &apiext.JSONSchemaProps{}
+	if err := json.Unmarshal(document.Bytes(), schema); err != nil {
+		t.Fatalf("document %s: %v", name, err)
+	}
+	return schema
+}
+
+// inMemoryBaseURI is the base URI of the documents generated in memory, which resolves their relative references
+const inMemoryBaseURI = "file:///schemas/"
+
+// compileInMemory compiles the schema at a location of documents generated in memory, such as
+// `validationpkg.json#/definitions/Schedule`. The documents are added under inMemoryBaseURI, or under the base
+// of an absolute location, such as a location under the base URI of the `$id` of the documents.
+func compileInMemory(t *testing.T, documents map[string]*memoryDocument, location string) *jsonschema.Schema {
+	t.Helper()
+	baseURI := inMemoryBaseURI
+	if documentPart, _, _ := strings.Cut(location, "#"); strings.Contains(documentPart, "://") {
+		baseURI = documentPart[:strings.LastIndex(documentPart, "/")+1]
+	} else {
+		location = baseURI + location
+	}
+	compiler := jsonschema.NewCompiler()
+	for name, document := range documents {
+		if err := compiler.AddResource(baseURI+name, bytes.NewReader(document.Bytes())); err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+	}
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	return schema
+}
+
+// validateInMemory validates JSON resources against the schema at a location of documents generated in memory
+func validateInMemory(t *testing.T, documents map[string]*memoryDocument, location string, tests []validationCase) {
+	t.Helper()
+	schema := compileInMemory(t, documents, location)
+	for _, tt := range tests {
+		var resource interface{}
+		if err := json.Unmarshal([]byte(tt.resource), &resource); err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if err := schema.Validate(resource); (err == nil) != tt.valid {
+			t.Errorf("%s: %s: expected valid=%v, got %v", location, tt.name, tt.valid, err)
+		}
+	}
+}
+
+// errorTestPkgs are the fixtures under testPkgs of the generation errors that the tests expect,
+// which the Makefile excludes from the test data as well
+var errorTestPkgs = []string{
+	"examplepkg", "extrapkg", "failfastpkg", "funcpkg", "genericpkg", "lengthpkg", "patternpkg", "sqlpkg", "unionpkg",
+}
+
+// validTestRoots returns the roots of the fixtures under testPkgs, except for the fixtures of errors
+func validTestRoots(t *testing.T) []string {
+	t.Helper()
+	entries, err := os.ReadDir("../../testPkgs")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	roots := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && indexOf(entry.Name(), errorTestPkgs) == -1 {
+			roots = append(roots, "../../testPkgs/"+entry.Name()+"/...")
+		}
+	}
+	return roots
+}
+
+func TestConcurrency(t *testing.T) {
+	allowDangerousTypes := true
+	sequential := generateInMemory(t, Generator{AllowDangerousTypes: &allowDangerousTypes}, LoadOptions{}, "../../testPkgs/...")
+	parallel := generateInMemory(t, Generator{AllowDangerousTypes: &allowDangerousTypes, Concurrency: 4}, LoadOptions{}, "../../testPkgs/...")
+	if len(parallel) != len(sequential) {
+		t.Fatalf("expected %d documents, got %d", len(sequential), len(parallel))
+	}
+	for name, document := range sequential {
+		if parallel[name] == nil || parallel[name].String() != document.String() {
+			t.Errorf("document %s differs when the roots are loaded in parallel", name)
+		}
+	}
+}
+
+func BenchmarkConcurrency(b *testing.B) {
+	allowDangerousTypes := true
+	for _, concurrency := range []int{1, 8} {
+		b.Run("concurrency="+strconv.Itoa(concurrency), func(b *testing.B) {
+			generator := Generator{AllowDangerousTypes: &allowDangerousTypes, Concurrency: concurrency}
+			var generators genall.Generators
+			var genallGenerator genall.Generator = &generator
+			generators = append(generators, &genallGenerator)
+			for i := 0; i < b.N; i++ {
+				// Note: the packages are listed outside of the measurement, which only covers their loading
+				b.StopTimer()
+				runtime, err := ForRoots(generators, LoadOptions{}, "../../testPkgs/...")
+				if err != nil {
+					b.Fatalf("error %v\n", err)
+				}
+				b.StartTimer()
+				if err := generator.GenerateTo(&runtime.GenerationContext, func(name string) (io.WriteCloser, error) {
+					return &memoryDocument{}, nil
+				}); err != nil {
+					b.Fatalf("error %v\n", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateTo(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	for _, name := range []string{"sample_crd.json", "schemapkg.json", "external.json"} {
+		schema := unmarshalDocument(t, documents, name)
+		if !documents[name].closed {
+			t.Errorf("document %s was not closed", name)
+		}
+		if schema.Title != name {
+			t.Errorf("document %s: unexpected title %s", name, schema.Title)
+		}
+	}
+}
+
+func TestPrunedRequired(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	document, exists := documents["optional_crd.json"]
+	if !exists {
+		t.Fatal("missing document optional_crd.json")
+	}
+	var schema map[string]json.RawMessage
+	if err := json.Unmarshal(document.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if required, exists := schema["required"]; exists {
+		t.Errorf("unexpected required list %s", required)
+	}
+	if _, exists := schema["properties"]; !exists {
+		t.Error("missing properties")
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	for _, trailingNewline := range []bool{false, true} {
+		documents := generateInMemory(t, Generator{TrailingNewline: trailingNewline}, LoadOptions{}, "../../testPkgs/fybrikobject")
+		for name, document := range documents {
+			if strings.HasSuffix(document.String(), "}\n") != trailingNewline {
+				t.Errorf("trailingNewline=%v: unexpected end of document %s: %q", trailingNewline, name, document.String())
+			}
+		}
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	for _, tt := range []struct {
+		failFast       bool
+		expectedErrors int
+	}{
+		{false, 2},
+		{true, 1},
+	} {
+		_, runtime, err := generateWithErrors(t, Generator{FailFast: tt.failFast}, LoadOptions{}, "../../testPkgs/failfastpkg")
+		reported := len(runtime.Roots[0].Errors)
+		if err != nil {
+			reported++
+			if !strings.Contains(err.Error(), "types.go:") {
+				t.Errorf("failFast=%v: missing position in error %v", tt.failFast, err)
+			}
+		}
+		if (err != nil) != tt.failFast || reported != tt.expectedErrors {
+			t.Errorf("failFast=%v: unexpected errors %v and %v", tt.failFast, err, runtime.Roots[0].Errors)
+		}
+	}
+}
+
+func TestInterfaceUnion(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Drawing", []validationCase{
+		{"variants", `{"shapes": {"a": {"radius": 1}, "b": {"side": 2}}}`, true},
+		{"invalid variant", `{"shapes": {"a": {"radius": -1}}}`, false},
+		{"no variant", `{"shapes": {"a": {"name": "x"}}}`, false},
+		{"several variants", `{"shapes": {"a": {"radius": 1, "side": 2}}}`, false},
+	})
+
+	_, runtime, err := generateWithErrors(t, Generator{}, LoadOptions{}, "../../testPkgs/unionpkg")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, expected := range []string{
+		`oneOf marker references unknown type "Missing"`,
+		`type "Label" of the oneOf marker doesn't implement "Shape"`,
+		"unsupported interface type",
+	} {
+		found := false
+		for _, err := range runtime.Roots[0].Errors {
+			found = found || strings.Contains(err.Error(), expected)
+		}
+		if !found {
+			t.Errorf("missing error %q in %v", expected, runtime.Roots[0].Errors)
+		}
+	}
+}
+
+func TestUnsupportedFieldType(t *testing.T) {
+	var warnings bytes.Buffer
+	_, runtime, err := generateWithErrors(t, Generator{Warnings: &warnings}, LoadOptions{}, "../../testPkgs/funcpkg")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	expected := `unsupported type func() of field "Routes" of type "Router" (AST kind *ast.FuncType): ` +
+		"encoding/json can't serialize functions and channels, exclude the field with the `json:\"-\"` tag"
+	if len(runtime.Roots[0].Errors) != 1 || !strings.Contains(runtime.Roots[0].Errors[0].Error(), expected) {
+		t.Errorf("expected the error %q, got %v", expected, runtime.Roots[0].Errors)
+	}
+
+	// the unserializable fields of Subscriber are skipped
+	for _, field := range []string{"notify", "events"} {
+		expected := `warning: field "` + field + `" of type "Subscriber" has type`
+		if !strings.Contains(warnings.String(), expected) {
+			t.Errorf("expected warning %q, got:\n%s", expected, warnings.String())
+		}
+	}
+}
+
+func TestExtraValues(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Extensible", []validationCase{
+		{"declared fields", `{"name": "a", "size": 1}`, true},
+		{"matching extra fields", `{"name": "a", "zone": "eu", "tier": "gold"}`, true},
+		{"extra field of another type", `{"name": "a", "zone": 1}`, false},
+		{"too long extra field", `{"name": "a", "zone": "europe-west"}`, false},
+		{"invalid declared field", `{"name": "a", "size": "1"}`, false},
+	})
+
+	// closing the structs keeps the schema of the extra values
+	documents := generateInMemory(t, Generator{ClosedStyle: additionalClosedStyle}, LoadOptions{}, "../../testPkgs/validationpkg")
+	extensible := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Extensible"]
+	if extensible.AdditionalProperties == nil || extensible.AdditionalProperties.Schema == nil ||
+		extensible.AdditionalProperties.Schema.Type != "string" {
+		t.Errorf("unexpected additionalProperties %+v of a closed struct with extra values", extensible.AdditionalProperties)
+	}
+
+	_, runtime, err := generateWithErrors(t, Generator{}, LoadOptions{}, "../../testPkgs/extrapkg")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, expected := range []string{
+		"the fybrik:validation:extraValues marker can only be applied to struct types",
+		"invalid extra values [1], expected a JSON schema object",
+		"the fybrik:validation:extraValues marker can't be combined with preserving the unknown fields",
+	} {
+		found := false
+		for _, err := range runtime.Roots[0].Errors {
+			found = found || strings.Contains(err.Error(), expected)
+		}
+		if !found {
+			t.Errorf("missing error %q in %v", expected, runtime.Roots[0].Errors)
+		}
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Boxes", []validationCase{
+		{"instantiations", `{"intBox": {"value": 1}, "pointBox": {"value": {"coordinates": [1, 2, 3]}, "label": "a"},
+			"aliased": {}, "entry": {"key": "a", "value": {"coordinates": [1, 2, 3]}}}`, true},
+		{"string as int", `{"intBox": {"value": "1"}, "pointBox": {}, "aliased": {},
+			"entry": {"key": "a", "value": {"coordinates": [1, 2, 3]}}}`, false},
+		{"invalid point", `{"intBox": {}, "pointBox": {"value": {"coordinates": [1]}}, "aliased": {},
+			"entry": {"key": "a", "value": {"coordinates": [1, 2, 3]}}}`, false},
+		{"empty label", `{"intBox": {"label": ""}, "pointBox": {}, "aliased": {},
+			"entry": {"key": "a", "value": {"coordinates": [1, 2, 3]}}}`, false},
+		{"missing pair value", `{"intBox": {}, "pointBox": {}, "aliased": {}, "entry": {"key": "a"}}`, false},
+	})
+
+	_, runtime, err := generateWithErrors(t, Generator{}, LoadOptions{}, "../../testPkgs/genericpkg")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, expected := range []string{
+		`generic type "List" has no schema of its own`,
+		"recursive generic type",
+	} {
+		found := false
+		for _, err := range runtime.Roots[0].Errors {
+			found = found || strings.Contains(err.Error(), expected)
+		}
+		if !found {
+			t.Errorf("missing error %q in %v", expected, runtime.Roots[0].Errors)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	for _, tt := range []struct {
+		maxDepth int
+		exceeded bool
+	}{
+		{0, false},
+		{5, false},
+		{3, true},
+	} {
+		_, runtime, err := generateWithErrors(t, Generator{MaxDepth: tt.maxDepth}, LoadOptions{}, "../../testPkgs/deeppkg")
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		exceeded := false
+		for _, err := range runtime.Roots[0].Errors {
+			exceeded = exceeded || strings.Contains(err.Error(), "maximum depth 3 exceeded at type")
+		}
+		if exceeded != tt.exceeded || !tt.exceeded && len(runtime.Roots[0].Errors) > 0 {
+			t.Errorf("maxDepth=%d: unexpected errors %v", tt.maxDepth, runtime.Roots[0].Errors)
+		}
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/buildtagpkg")
+	if _, exists := unmarshalDocument(t, documents, "buildtagpkg.json").Definitions["TaggedType"]; exists {
+		t.Error("unexpected definition of a type behind an unset build tag")
+	}
+
+	documents = generateInMemory(t, Generator{}, LoadOptions{BuildTags: []string{"schemagen"}}, "../../testPkgs/buildtagpkg")
+	if _, exists := unmarshalDocument(t, documents, "buildtagpkg.json").Definitions["TaggedType"]; !exists {
+		t.Error("missing definition of a type behind a set build tag")
+	}
+}
+
+func TestExternalBaseURI(t *testing.T) {
+	externalPath, err := filepath.Abs("../../testdata/schema/external.json")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	documentPath, err := filepath.Abs("../../testdata/schema/validationpkg.json")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	// external.json is resolved through its $id, without fetching the absolute URI
+	schemaLoader := gojsonschema.NewSchemaLoader()
+	if err := schemaLoader.AddSchemas(gojsonschema.NewReferenceLoader("file://" + externalPath)); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	schema, err := schemaLoader.Compile(gojsonschema.NewReferenceLoader("file://" + documentPath + "#/definitions/ExternalRef"))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, tt := range []validationCase{
+		{"valid", `{"field": {"count": 1}}`, true},
+		{"invalid external type", `{"field": {"count": -1}}`, false},
+	} {
+		result, err := schema.Validate(gojsonschema.NewStringLoader(tt.resource))
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if result.Valid() != tt.valid {
+			t.Errorf("%s: expected valid=%v, got errors %v", tt.name, tt.valid, result.Errors())
+		}
+	}
+}
+
+func TestIncludeTests(t *testing.T) {
+	for _, tests := range []bool{false, true} {
+		documents := generateInMemory(t, Generator{}, LoadOptions{Tests: tests}, "../../testPkgs/testfilepkg")
+		definitions := unmarshalDocument(t, documents, "testfilepkg.json").Definitions
+		if _, exists := definitions["RegularType"]; !exists {
+			t.Errorf("tests=%v: missing definition of a type in a regular file", tests)
+		}
+		if _, exists := definitions["FixtureType"]; exists != tests {
+			t.Errorf("tests=%v: unexpected presence %v of a type in a test file", tests, exists)
+		}
+	}
+}
+
+func TestRelativeRootDir(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{Dir: "../../testPkgs"}, "./buildtagpkg")
+	if _, exists := unmarshalDocument(t, documents, "buildtagpkg.json").Definitions["UntaggedType"]; !exists {
+		t.Error("missing definition of a type in a root relative to the load directory")
+	}
+}
+
+func TestFloatBounds(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "FloatBounds", []validationCase{
+		{"within bounds", `{"probability": 0.5, "ratio": 1}`, true},
+		{"field over maximum", `{"probability": 1.5, "ratio": 0.5}`, false},
+		{"field under minimum", `{"probability": -0.1, "ratio": 0.5}`, false},
+		{"named type over maximum", `{"probability": 0.5, "ratio": 2}`, false},
+	})
+}
+
+func TestPruneUnreferenced(t *testing.T) {
+	documents := generateInMemory(t, Generator{PruneUnreferenced: true}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	definitions := unmarshalDocument(t, documents, "schemapkg.json").Definitions
+	if _, exists := definitions["SchemaType1"]; !exists {
+		t.Error("missing definition of a referenced type")
+	}
+	if _, exists := definitions["UnusedType"]; exists {
+		t.Error("unexpected definition of an unreferenced type")
+	}
+}
+
+func TestMarkersHelp(t *testing.T) {
+	markersHelp, err := Generator{}.MarkersHelp()
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	listed := make(map[string]MarkerHelp)
+	for _, markerHelp := range markersHelp {
+		listed[markerHelp.Name+" "+markerHelp.Target] = markerHelp
+		if strings.HasPrefix(markerHelp.Name, defaultMarkerPrefix) && markerHelp.Category == Empty {
+			t.Errorf("missing category of marker %s", markerHelp.Name)
+		}
+	}
+	for def, category := range map[*markers.Definition]string{
+		schemaMarker:       generationCategory,
+		objectMarker:       generationCategory,
+		groupMarker:        generationCategory,
+		timeFormatMarker:   validationCategory,
+		keyMaxLengthMarker: validationCategory,
+		keyPatternMarker:   validationCategory,
+		titleMarker:        annotationCategory,
+		secretMarker:       annotationCategory,
+	} {
+		markerHelp, exists := listed[def.Name+" "+def.Target.String()]
+		if !exists {
+			t.Errorf("missing marker %s", def.Name)
+			continue
+		}
+		if markerHelp.Category != category || markerHelp.Summary == Empty {
+			t.Errorf("unexpected help for marker %s: %+v", def.Name, markerHelp)
+		}
+	}
+	if _, exists := listed["kubebuilder:validation:Required field"]; !exists {
+		t.Error("missing kubebuilder marker")
+	}
+
+	// the markers are listed under the marker prefix, with the help of the default markers
+	markersHelp, err = Generator{MarkerPrefix: "acme:validation"}.MarkersHelp()
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	prefixed := false
+	for _, markerHelp := range markersHelp {
+		if strings.HasPrefix(markerHelp.Name, defaultMarkerPrefix) {
+			t.Errorf("unexpected marker %s with the default prefix", markerHelp.Name)
+		}
+		if markerHelp.Name == "acme:validation:title" {
+			prefixed = markerHelp.Category == annotationCategory
+		}
+	}
+	if !prefixed {
+		t.Error("missing the help of the prefixed title marker")
+	}
+}
+
+func TestMarkerPrefix(t *testing.T) {
+	documents := generateInMemory(t, Generator{MarkerPrefix: "acme:validation"}, LoadOptions{}, "../../testPkgs/prefixpkg")
+	if color := unmarshalDocument(t, documents, "acme_widget.json").Properties["color"]; color.Title != "Color" {
+		t.Errorf("unexpected schema of a field with a prefixed marker %+v", color)
+	}
+	if color := unmarshalDocument(t, documents, "prefixpkg.json").Definitions["Color"]; len(color.Enum) != 2 {
+		t.Errorf("unexpected schema of a type with a prefixed marker %+v", color)
+	}
+
+	documents = generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/prefixpkg")
+	if _, exists := documents["acme_widget.json"]; exists {
+		t.Error("unexpected object document for a marker with another prefix")
+	}
+
+	registry := &markers.Registry{}
+	if err := (Generator{MarkerPrefix: "acme:validation"}).RegisterMarkers(registry); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if registry.Lookup("+acme:validation:object", markers.DescribesType) == nil ||
+		registry.Lookup("+fybrik:validation:object", markers.DescribesType) != nil {
+		t.Error("unexpected registered object markers")
+	}
+}
+
+func TestIntegerEnum(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Leveled", []validationCase{
+		{"enum value", `{"level": 2}`, true},
+		{"value not in enum", `{"level": 4}`, false},
+		{"string value", `{"level": "2"}`, false},
+	})
+}
+
+func TestBooleanEnum(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Flagged", []validationCase{
+		{"enum values", `{"flag": true, "accepted": true, "disabled": false}`, true},
+		{"false flag", `{"flag": false, "accepted": true, "disabled": false}`, false},
+		{"false from the fybrik enum marker", `{"flag": true, "accepted": false, "disabled": false}`, false},
+		{"true field", `{"flag": true, "accepted": true, "disabled": true}`, false},
+		{"string value", `{"flag": "true", "accepted": true, "disabled": false}`, false},
+	})
+}
+
+func TestCheckEnumType(t *testing.T) {
+	schema := &apiext.JSONSchemaProps{Type: "integer", Enum: []apiext.JSON{{Raw: []byte(`1`)}, {Raw: []byte(`2`)}}}
+	if err := checkEnumType(schema); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	schema.Enum = append(schema.Enum, apiext.JSON{Raw: []byte(`"3"`)})
+	if err := checkEnumType(schema); err == nil {
+		t.Error("expected an error for a string value of an integer enum")
+	}
+	schema = &apiext.JSONSchemaProps{Type: "boolean", Enum: []apiext.JSON{{Raw: []byte(`true`)}}}
+	if err := checkEnumType(schema); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	schema.Enum = []apiext.JSON{{Raw: []byte(`"true"`)}}
+	if err := checkEnumType(schema); err == nil {
+		t.Error("expected an error for a string value of a boolean enum")
+	}
+}
+
+// loadDocument parses a generated schema document
+func loadDocument(t *testing.T, document string) *apiext.JSONSchemaProps {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("../../testdata/schema", document))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	schema := &apiext.JSONSchemaProps{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		t.Fatalf("document %s: %v", document, err)
+	}
+	return schema
+}
+
+func TestFieldTitle(t *testing.T) {
+	properties := loadDocument(t, "validationpkg.json").Definitions["Titled"].Properties
+	if title := properties["name"].Title; title != "Dataset name" {
+		t.Errorf("unexpected title %q", title)
+	}
+	if description := properties["name"].Description; description != "The name of the dataset" {
+		t.Errorf("unexpected description %q", description)
+	}
+	if title := properties["description"].Title; title != Empty {
+		t.Errorf("unexpected title %q of a field without the title marker", title)
+	}
+}
+
+func TestRecursiveRefs(t *testing.T) {
+	documents := generateInMemory(t, Generator{RecursiveRefs: true}, LoadOptions{}, "../../testPkgs/recursivepkg")
+	validateInMemory(t, documents, "recursivepkg.json#/definitions/Node", []validationCase{
+		{"nested", `{"name": "a", "children": [{"name": "b", "children": [{"name": "c", "leaf": {"value": 1}}]}]}`, true},
+		{"invalid nested node", `{"name": "a", "children": [{"name": "b", "children": [{"name": ""}]}]}`, false},
+		{"invalid nested leaf", `{"name": "a", "children": [{"name": "b", "leaf": {"value": "1"}}]}`, false},
+	})
+}
+
+func TestExtension(t *testing.T) {
+	extension := ".schema.json"
+	documents := generateInMemory(t, Generator{Extension: &extension}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	for _, name := range []string{"sample_crd.schema.json", "schemapkg.schema.json", "external.schema.json"} {
+		if schema := unmarshalDocument(t, documents, name); schema.Title != name {
+			t.Errorf("document %s: unexpected title %s", name, schema.Title)
+		}
+	}
+	ref := unmarshalDocument(t, documents, "sample_crd.schema.json").Definitions["Type1"].Properties["type1f1"].Ref
+	if ref == nil || *ref != "schemapkg.schema.json#/definitions/SchemaType1" {
+		t.Errorf("unexpected cross-document reference %v", ref)
+	}
+}
+
+func TestObjectTitle(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if schema := unmarshalDocument(t, documents, "optional_crd.json"); schema.Title != "Optional CRD" {
+		t.Errorf("unexpected title %s", schema.Title)
+	}
+	if schema := unmarshalDocument(t, documents, "sample_crd.json"); schema.Title != "sample_crd.json" {
+		t.Errorf("unexpected title %s", schema.Title)
+	}
+}
+
+func TestParseObjName(t *testing.T) {
+	for _, tt := range []struct {
+		raw      string
+		expected ObjName
+		valid    bool
+	}{
+		{`"sample_crd"`, ObjName{Name: "sample_crd"}, true},
+		{`sample_crd`, ObjName{Name: "sample_crd"}, true},
+		{`{"name": "sample_crd", "title": "Sample CRD"}`, ObjName{Name: "sample_crd", Title: "Sample CRD"}, true},
+		{`{"title": "Sample CRD"}`, ObjName{}, false},
+		{`{"name": 1}`, ObjName{}, false},
+	} {
+		objName, err := parseObjName(markers.RawArguments(tt.raw))
+		if (err == nil) != tt.valid || tt.valid && objName != tt.expected {
+			t.Errorf("%s: unexpected object name %+v, error %v", tt.raw, objName, err)
+		}
+	}
+}
+
+func TestPointerRefEncoding(t *testing.T) {
+	for token, expected := range map[string]string{
+		"Type":                 "Type",
+		"a/b":                  "a~1b",
+		"a~b":                  "a~0b",
+		"fybrik.io~1pkg~0Type": "fybrik.io~01pkg~00Type",
+	} {
+		if escaped := escapeJSONPointer(token); escaped != expected {
+			t.Errorf("%s: expected %s, got %s", token, expected, escaped)
+		}
+	}
+
+	// the qualified names of definitions contain `~` characters, which the fragments of references escape
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/validationpkg", "../../testPkgs/externalpkg")
+	definitionName := qualifiedName("fybrik.io/json-schema-generator/testPkgs/externalpkg", "ExternalType")
+	if _, exists := unmarshalDocument(t, documents, "external.json").Definitions[definitionName]; !exists {
+		t.Fatalf("expected the definition %s in external.json", definitionName)
+	}
+	ref := unmarshalDocument(t, documents, "validationpkg.json").Definitions["ExternalRef"].Properties["field"].Ref
+	expected := "external.json#/definitions/fybrik.io~01json-schema-generator~01testPkgs~01externalpkg~00ExternalType"
+	if ref == nil || *ref != expected {
+		t.Fatalf("unexpected cross-package reference %v", ref)
+	}
+	validateInMemory(t, documents, "validationpkg.json#/definitions/ExternalRef", []validationCase{
+		{"valid", `{"field": {"count": 1}}`, true},
+		{"invalid", `{"field": {"count": -1}}`, false},
+	})
+}
+
+func TestPercentRefEncoding(t *testing.T) {
+	documents := generateInMemory(t, Generator{RefEncoding: "percent"}, LoadOptions{},
+		"../../testPkgs/validationpkg", "../../testPkgs/externalpkg")
+	ref := unmarshalDocument(t, documents, "validationpkg.json").Definitions["ExternalRef"].Properties["field"].Ref
+	expected := "external.json#/definitions/fybrik.io%7E01json-schema-generator%7E01testPkgs%7E01externalpkg%7E00ExternalType"
+	if ref == nil || *ref != expected {
+		t.Fatalf("unexpected cross-package reference %v", *ref)
+	}
+
+	// the percent-encoded fragment resolves to the JSON-pointer-escaped definition, which gojsonschema decodes
+	dir := t.TempDir()
+	for name, document := range documents {
+		if err := os.WriteFile(filepath.Join(dir, name), document.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader(
+		"file://" + filepath.Join(dir, "validationpkg.json") + "#/definitions/ExternalRef"))
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, tt := range []validationCase{
+		{"valid", `{"field": {"count": 1}}`, true},
+		{"invalid external type", `{"field": {"count": -1}}`, false},
+	} {
+		result, err := schema.Validate(gojsonschema.NewStringLoader(tt.resource))
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if result.Valid() != tt.valid {
+			t.Errorf("%s: expected valid=%v, got errors %v", tt.name, tt.valid, result.Errors())
+		}
+	}
+}
+
+func TestUnsupportedRefEncoding(t *testing.T) {
+	_, _, err := generateWithErrors(t, Generator{RefEncoding: "base64"}, LoadOptions{}, "../../testPkgs/externalpkg")
+	if err == nil || !strings.Contains(err.Error(), "unsupported ref encoding base64") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestExampleTag(t *testing.T) {
+	documents := generateInMemory(t, Generator{ExampleTag: "example"}, LoadOptions{}, "../../testPkgs/validationpkg")
+	properties := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Exemplified"].Properties
+	for name, expected := range map[string]string{
+		"name":     `"foo"`,
+		"replicas": `3`,
+		"zones":    `["eu","us"]`,
+	} {
+		example := properties[name].Example
+		if example == nil {
+			t.Errorf("property %s: missing example", name)
+			continue
+		}
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, example.Raw); err != nil || compacted.String() != expected {
+			t.Errorf("property %s: unexpected example %s", name, example.Raw)
+		}
+	}
+	if example := properties["comment"].Example; example != nil {
+		t.Errorf("unexpected example %s", example.Raw)
+	}
+}
+
+func TestValidateExamples(t *testing.T) {
+	generateInMemory(t, Generator{ExampleTag: "example", ValidateExamples: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+
+	_, _, err := generateWithErrors(t, Generator{ExampleTag: "example", ValidateExamples: true}, LoadOptions{}, "../../testPkgs/examplepkg")
+	if err == nil {
+		t.Fatal("expected an error for the invalid examples")
+	}
+	for _, expected := range []string{
+		"document examplepkg.json, Server.port: example 0: Must be greater than or equal to 1",
+		`document examplepkg.json, Server.mode: example "fast":`,
+		`must be one of the following: "slow", "safe"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("missing error %q in %v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Server.host") {
+		t.Errorf("unexpected error for a valid example in %v", err)
+	}
+}
+
+func TestSecretFields(t *testing.T) {
+	documents := generateInMemory(t, Generator{ExampleTag: "example"}, LoadOptions{}, "../../testPkgs/validationpkg")
+	var document struct {
+		Definitions map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(documents["validationpkg.json"].Bytes(), &document); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	properties := document.Definitions["Credentials"].Properties
+	for name, expected := range map[string]map[string]interface{}{
+		"user":     {"type": "string", "example": "admin"},
+		"password": {"type": "string", "format": "password", "writeOnly": true},
+		"token":    {"type": "string", "format": "password", "writeOnly": true},
+		"pin":      {"type": "integer", "format": "int64", "writeOnly": true},
+	} {
+		if !reflect.DeepEqual(properties[name], expected) {
+			t.Errorf("property %s: unexpected schema %v", name, properties[name])
+		}
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/validationpkg")
+	var document struct {
+		Definitions map[string]map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal(documents["validationpkg.json"].Bytes(), &document); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for name, expected := range map[string]interface{}{
+		"Payload": float64(4096),
+		"Token":   float64(64),
+		"Upload":  nil,
+	} {
+		if maxBytes := document.Definitions[name]["x-max-bytes"]; maxBytes != expected {
+			t.Errorf("type %s: expected x-max-bytes %v, got %v", name, expected, maxBytes)
+		}
+	}
+
+	if err := MaxBytes(0).ApplyToSchema(&apiext.JSONSchemaProps{Type: "string"}); err == nil {
+		t.Error("expected an error for a non-positive size")
+	}
+}
+
+func TestAnonymousStructs(t *testing.T) {
+	cases := []validationCase{
+		{"endpoints", `{"primary": {"host": "a", "port": 80}, "fallback": {"host": "b", "port": 8080}, "options": {}}`, true},
+		{"invalid port", `{"primary": {"host": "a", "port": 0}, "options": {}}`, false},
+		{"invalid fallback port", `{"primary": {"host": "a", "port": 80}, "fallback": {"host": "b", "port": 0}, "options": {}}`, false},
+		{"missing host", `{"primary": {"port": 80}, "options": {}}`, false},
+	}
+	validateDefinition(t, "validationpkg.json", "Endpoints", cases)
+
+	documents := generateInMemory(t, Generator{HoistAnonymous: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	if strings.Contains(documents["validationpkg.json"].String(), anonymousKeyword) {
+		t.Errorf("unexpected %s in the document", anonymousKeyword)
+	}
+	definitions := unmarshalDocument(t, documents, "validationpkg.json").Definitions
+	hoisted := 0
+	for name := range definitions {
+		if strings.HasPrefix(name, "Endpoints") && name != "Endpoints" {
+			hoisted++
+		}
+	}
+	if hoisted != 1 {
+		t.Errorf("expected a single hoisted definition, got %d", hoisted)
+	}
+	properties := definitions["Endpoints"].Properties
+	for _, name := range []string{"primary", "fallback"} {
+		if ref := properties[name].Ref; ref == nil || *ref != "#/definitions/EndpointsFallback" {
+			t.Errorf("property %s: unexpected ref %v", name, ref)
+		}
+	}
+	if properties["options"].Ref != nil {
+		t.Error("unexpected hoisted options")
+	}
+
+	validateInMemory(t, documents, "validationpkg.json#/definitions/Endpoints", cases)
+}
+
+func TestEmitManifest(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if _, exists := documents["manifest.json"]; exists {
+		t.Error("unexpected manifest without the option")
+	}
+
+	for _, definitionsRoot := range []string{Empty, "$defs"} {
+		generator := Generator{EmitManifest: true, ExternalBaseURI: "https://fybrik.io/schemas", DefinitionsRoot: definitionsRoot}
+		documents := generateInMemory(t, generator, LoadOptions{}, "../../testPkgs/fybrikobject")
+		var m manifest
+		if err := json.Unmarshal(documents["manifest.json"].Bytes(), &m); err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if len(m.Documents) != len(documents)-1 {
+			t.Errorf("expected %d documents in the manifest, got %+v", len(documents)-1, m.Documents)
+		}
+		for _, described := range m.Documents {
+			document := unmarshalDocument(t, documents, described.Name)
+			var definitions []string
+			if definitionsRoot == Empty {
+				definitions = sortedKeys(document.Definitions, nil)
+			} else {
+				var raw map[string]json.RawMessage
+				var moved map[string]json.RawMessage
+				if err := json.Unmarshal(documents[described.Name].Bytes(), &raw); err != nil {
+					t.Fatalf("error %v\n", err)
+				}
+				if err := json.Unmarshal(raw[definitionsRoot], &moved); err != nil {
+					t.Fatalf("error %v\n", err)
+				}
+				definitions = sortedKeys(moved, nil)
+			}
+			if !reflect.DeepEqual(described.Definitions, definitions) || described.Title != document.Title ||
+				described.ID != "https://fybrik.io/schemas/"+described.Name {
+				t.Errorf("unexpected description %+v of document %s with definitions %v", described, described.Name, definitions)
+			}
+		}
+		expected := []manifestEntry{
+			{"optional_crd.json", "fybrik.io/json-schema-generator/testPkgs/fybrikobject.OptionalCrd"},
+			{"sample_crd.json", "fybrik.io/json-schema-generator/testPkgs/fybrikobject.SampleCrd"},
+		}
+		if !reflect.DeepEqual(m.Entries, expected) {
+			t.Errorf("expected the entries %+v, got %+v", expected, m.Entries)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for _, tt := range []struct {
+		redact   bool
+		expected bool
+	}{
+		{false, true},
+		{true, false},
+	} {
+		documents := generateInMemory(t, Generator{ExampleTag: "example", Redact: tt.redact}, LoadOptions{}, "../../testPkgs/validationpkg")
+		data := documents["validationpkg.json"].String()
+		for _, keyword := range []string{`"default"`, `"example"`} {
+			if strings.Contains(data, keyword) != tt.expected {
+				t.Errorf("redact=%v: expected %s=%v in %s", tt.redact, keyword, tt.expected, data)
+			}
+		}
+	}
+
+	// the validation is kept
+	documents := generateInMemory(t, Generator{ExampleTag: "example", Redact: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	exemplified := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Exemplified"]
+	if len(exemplified.Required) != 4 || exemplified.Properties["retries"].Type != "integer" {
+		t.Errorf("unexpected redacted schema %v", exemplified)
+	}
+}
+
+func TestCoalesceCycles(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+	for _, name := range []string{"alpha.fybrik.io.json", "beta.fybrik.io.json"} {
+		unmarshalDocument(t, documents, name)
+	}
+
+	documents = generateInMemory(t, Generator{CoalesceCycles: true}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+	if _, exists := documents["beta.fybrik.io.json"]; exists {
+		t.Errorf("unexpected coalesced document beta.fybrik.io.json")
+	}
+	coalesced := unmarshalDocument(t, documents, "alpha.fybrik.io.json")
+	for _, name := range []string{"Order", "Item", "Price"} {
+		if _, exists := coalesced.Definitions[name]; !exists {
+			t.Errorf("missing definition %s in %v", name, coalesced.Definitions)
+		}
+	}
+	if data := documents["alpha.fybrik.io.json"].String(); strings.Contains(data, "beta.fybrik.io.json") {
+		t.Errorf("unexpected reference to the coalesced document in %s", data)
+	}
+
+	validateInMemory(t, documents, "alpha.fybrik.io.json#/definitions/Order", []validationCase{
+		{"valid order", `{"items": [{"name": "a", "price": {"amount": 1, "currency": "EUR"}}]}`, true},
+		{"negative price", `{"items": [{"name": "a", "price": {"amount": -1, "currency": "EUR"}}]}`, false},
+	})
+}
+
+func TestHarvestDeprecations(t *testing.T) {
+	type schema struct {
+		Description string            `json:"description"`
+		Deprecated  bool              `json:"deprecated"`
+		Properties  map[string]schema `json:"properties"`
+	}
+	for _, harvest := range []bool{false, true} {
+		documents := generateInMemory(t, Generator{HarvestDeprecations: harvest}, LoadOptions{}, "../../testPkgs/validationpkg")
+		var document struct {
+			Definitions map[string]schema `json:"definitions"`
+		}
+		if err := json.Unmarshal(documents["validationpkg.json"].Bytes(), &document); err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		relocated := document.Definitions["Relocated"]
+		if endpoint := relocated.Properties["endpoint"]; endpoint.Deprecated != harvest ||
+			!strings.Contains(endpoint.Description, "Deprecated: use Address instead.") {
+			t.Errorf("harvest=%v: unexpected schema of a deprecated field %+v", harvest, endpoint)
+		}
+		if address := relocated.Properties["address"]; address.Deprecated {
+			t.Errorf("harvest=%v: unexpected deprecation of a field that mentions it mid-paragraph %+v", harvest, address)
+		}
+		if settings := document.Definitions["LegacySettings"]; settings.Deprecated != harvest {
+			t.Errorf("harvest=%v: unexpected schema of a deprecated type %+v", harvest, settings)
+		}
+	}
+}
+
+func TestStripK8sExtensions(t *testing.T) {
+	for _, tt := range []struct {
+		strip    bool
+		expected bool
+	}{
+		{false, true},
+		{true, false},
+	} {
+		documents := generateInMemory(t, Generator{StripK8sExtensions: tt.strip}, LoadOptions{}, "../../testPkgs/validationpkg")
+		data := documents["validationpkg.json"].String()
+		if strings.Contains(data, "x-kubernetes-") != tt.expected {
+			t.Errorf("strip=%v: expected x-kubernetes-* extensions=%v in %s", tt.strip, tt.expected, data)
+		}
+	}
+}
+
+func TestRawExtension(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Embedding", []validationCase{
+		{"empty object", `{"embedded": {}}`, true},
+		{"arbitrary object", `{"embedded": {"kind": "Pod", "spec": {"containers": [{"name": "x"}]}, "replicas": 3}}`, true},
+		{"not an object", `{"embedded": "raw"}`, false},
+	})
+}
+
+func TestFieldObject(t *testing.T) {
+	document := loadDocument(t, "validationpkg.json")
+	ref := document.Definitions["Composite"].Properties["part"].Ref
+	if ref == nil || *ref != "split_part.json" {
+		t.Errorf("unexpected reference to the split document %v", ref)
+	}
+	if title := loadDocument(t, "split_part.json").Title; title != "split_part.json" {
+		t.Errorf("unexpected title %s", title)
+	}
+	validateDefinition(t, "validationpkg.json", "Composite", []validationCase{
+		{"valid", `{"part": {"name": "a", "level": 1}}`, true},
+		{"missing field of split type", `{"part": {"name": "a"}}`, false},
+		{"invalid reference of split type", `{"part": {"name": "a", "level": "high"}}`, false},
+	})
+}
+
+func TestLocalizedDescriptions(t *testing.T) {
+	documents := generateInMemory(t, Generator{Descriptions: "../../testPkgs/descriptions.json", Lang: "fr"}, LoadOptions{},
+		"../../testPkgs/validationpkg")
+	titled := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Titled"]
+	if titled.Description != "Un jeu de données titré" {
+		t.Errorf("unexpected type description %q", titled.Description)
+	}
+	if description := titled.Properties["name"].Description; description != "Le nom du jeu de données" {
+		t.Errorf("unexpected field description %q", description)
+	}
+	if description := titled.Properties["description"].Description; description != Empty {
+		t.Errorf("unexpected description of a field without a localized description %q", description)
+	}
+}
+
+func TestEnumDescriptions(t *testing.T) {
+	documents := generateInMemory(t, Generator{EnumDescriptions: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	var document struct {
+		Definitions map[string]struct {
+			Enum         []interface{} `json:"enum"`
+			Descriptions []string      `json:"x-enum-descriptions"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(documents["validationpkg.json"].Bytes(), &document); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	priority := document.Definitions["Priority"]
+	if !reflect.DeepEqual(priority.Enum, []interface{}{0.0, 1.0, 2.0}) {
+		t.Errorf("unexpected enum %v", priority.Enum)
+	}
+	expected := []string{
+		"Low priority requests are served last",
+		"Medium priority requests are served before low priority ones",
+		"High priority requests are served first",
+	}
+	if !reflect.DeepEqual(priority.Descriptions, expected) {
+		t.Errorf("unexpected enum descriptions %q", priority.Descriptions)
+	}
+	// the enum of a marker is kept
+	level := document.Definitions["Level"]
+	if !reflect.DeepEqual(level.Enum, []interface{}{1.0, 2.0, 3.0}) || level.Descriptions != nil {
+		t.Errorf("unexpected enum %v with descriptions %q", level.Enum, level.Descriptions)
+	}
+	// the enum is inferred by default, without the descriptions of the option
+	validateDefinition(t, "validationpkg.json", "Prioritized", []validationCase{
+		{"value of a constant", `{"priority": 1}`, true},
+		{"value of no constant", `{"priority": 7}`, false},
+	})
+}
+
+func TestRootRefOnly(t *testing.T) {
+	documents := generateInMemory(t, Generator{RootRefOnly: true}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	root := unmarshalDocument(t, documents, "root.json")
+	refs := []string{}
+	for _, subschema := range root.AnyOf {
+		if subschema.Ref == nil {
+			t.Fatalf("unexpected subschema without a reference %+v", subschema)
+		}
+		refs = append(refs, *subschema.Ref)
+		if _, exists := documents[*subschema.Ref]; !exists {
+			t.Errorf("reference %s to a missing document", *subschema.Ref)
+		}
+	}
+	if !reflect.DeepEqual(refs, []string{"optional_crd.json", "sample_crd.json"}) {
+		t.Errorf("unexpected references %v", refs)
+	}
+	if len(root.Properties) != 0 || len(root.Definitions) != 0 {
+		t.Error("unexpected content of the root document")
+	}
+
+	// the references resolve to the documents, which all reject an invalid object
+	validateInMemory(t, documents, "root.json", []validationCase{{"invalid object", `{"field1": {"type1f1": 5}}`, false}})
+}
+
+func TestNetworkTypes(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	marshaled, err := json.Marshal(validationpkg.Network{Address: net.ParseIP("10.0.0.1"), Subnet: *subnet})
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	validateDefinition(t, "validationpkg.json", "Network", []validationCase{
+		{"marshaled network", string(marshaled), true},
+		{"ipv4", `{"address": "10.0.0.1", "subnet": {"IP": "10.0.0.0", "Mask": "/wAAAA=="}}`, true},
+		{"ipv6", `{"address": "2001:db8::1", "subnet": {"IP": "2001:db8::", "Mask": "/////wAAAAAAAAAAAAAAAA=="}}`, true},
+		{"invalid address", `{"address": "10.0.0.256", "subnet": {"IP": "10.0.0.0", "Mask": "/wAAAA=="}}`, false},
+		{"address with prefix length", `{"address": "10.0.0.1/8", "subnet": {"IP": "10.0.0.0", "Mask": "/wAAAA=="}}`, false},
+		{"subnet as text", `{"address": "10.0.0.1", "subnet": "10.0.0.0/8"}`, false},
+		{"subnet without mask", `{"address": "10.0.0.1", "subnet": {"IP": "10.0.0.0"}}`, false},
+		{"byte array address", `{"address": [10, 0, 0, 1], "subnet": {"IP": "10.0.0.0", "Mask": "/wAAAA=="}}`, false},
+	})
+}
+
+func TestBigNumbers(t *testing.T) {
+	total, isInt := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	if !isInt {
+		t.Fatalf("invalid integer")
+	}
+	// Note: big.Int has a pointer receiver marshaler, which encoding/json only calls for addressable values
+	marshaled, err := json.Marshal(&validationpkg.Amounts{Total: *total, Ratio: big.NewFloat(0.5)})
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	validateDefinition(t, "validationpkg.json", "Amounts", []validationCase{
+		{"marshaled amounts", string(marshaled), true},
+		{"integer and string", `{"total": -123456789012345678901234567890, "ratio": "0.5"}`, true},
+		{"fractional integer", `{"total": 1.5, "ratio": "0.5"}`, false},
+		{"integer string", `{"total": "1", "ratio": "0.5"}`, false},
+		{"numeric ratio", `{"total": 1, "ratio": 0.5}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{BigNumbers: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	amounts := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Amounts"]
+	if total := amounts.Properties["total"]; total.Type != "integer" || total.Pattern != Empty {
+		t.Errorf("unexpected big.Int schema %+v", total)
+	}
+	if ratio := amounts.Properties["ratio"]; ratio.Type != "number" {
+		t.Errorf("unexpected big.Float schema %+v", ratio)
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/validationpkg")
+	value := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Measurement"].Properties["value"]
+	if value.Ref == nil || *value.Ref != "external.json#/definitions/encoding~01json~00Number" {
+		t.Errorf("unexpected default json.Number schema %+v", value)
+	}
+
+	documents = generateInMemory(t, Generator{JSONNumbers: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/Measurement", []validationCase{
+		{"number", `{"value": 1.5}`, true},
+		{"numeric string", `{"value": "-12.5e3"}`, true},
+		{"integer string", `{"value": "42"}`, true},
+		{"non-numeric string", `{"value": "abc"}`, false},
+		{"leading zero", `{"value": "01"}`, false},
+		{"boolean", `{"value": true}`, false},
+	})
+}
+
+func TestSQLNullScalars(t *testing.T) {
+	documents := generateInMemory(t, Generator{SQLNullScalars: true}, LoadOptions{}, "../../testPkgs/sqlpkg")
+	validateInMemory(t, documents, "sqlpkg.json#/definitions/Record", []validationCase{
+		{"values", `{"name": "a", "count": 1}`, true},
+		{"nulls", `{"name": null, "count": null}`, true},
+		{"struct form", `{"name": {"String": "a", "Valid": true}, "count": 1}`, false},
+		{"fractional count", `{"name": "a", "count": 1.5}`, false},
+	})
+}
+
+func TestUUID(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Identified", []validationCase{
+		{"valid", `{"uid": "123e4567-e89b-12d3-a456-426614174000"}`, true},
+		{"not a UUID", `{"uid": "123e4567"}`, false},
+		{"byte array", `{"uid": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16]}`, false},
+	})
+}
+
+func TestTypeOverrides(t *testing.T) {
+	documents := generateInMemory(t, Generator{TypeOverrides: "../../testPkgs/type_overrides.json"}, LoadOptions{},
+		"../../testPkgs/validationpkg", "../../testPkgs/externalpkg")
+	id := unmarshalDocument(t, documents, "validationpkg.json").Definitions["CustomIdentified"].Properties["id"]
+	if id.Type != "string" || id.Format != "uuid" || id.Ref != nil {
+		t.Errorf("unexpected schema of an overridden type %+v", id)
+	}
+}
+
+func TestClosedStyle(t *testing.T) {
+	for style, definitions := range map[string]map[string][]validationCase{
+		// Note: additionalProperties can't see the properties of embedded bases, so the structs that embed them are left open
+		"additional": {
+			"Derived": {
+				{"inherited", `{"name": "a", "size": 1}`, true},
+				{"unknown", `{"name": "a", "size": 1, "color": "red"}`, true},
+			},
+			"Point": {{"unknown", `{"coordinates": [1, 2, 3], "color": "red"}`, false}},
+			"Base":  {{"embedded base", `{"name": "a", "size": 1}`, true}},
+		},
+		"unevaluated": {
+			"Derived": {
+				{"inherited", `{"name": "a", "size": 1}`, true},
+				{"unknown", `{"name": "a", "size": 1, "color": "red"}`, false},
+			},
+			"Point": {{"unknown", `{"coordinates": [1, 2, 3], "color": "red"}`, false}},
+			"Base":  {{"embedded base", `{"name": "a", "size": 1}`, true}},
+		},
+	} {
+		documents := generateInMemory(t, Generator{ClosedStyle: style}, LoadOptions{}, "../../testPkgs/validationpkg")
+		for definition, tests := range definitions {
+			validateInMemory(t, documents, "validationpkg.json#/definitions/"+definition, tests)
+		}
+		data := documents["validationpkg.json"].Bytes()
+		if unevaluated := bytes.Contains(data, []byte(`"unevaluatedProperties"`)); unevaluated != (style == "unevaluated") {
+			t.Errorf("%s: unexpected unevaluatedProperties=%v", style, unevaluated)
+		}
+		if document := unmarshalDocument(t, documents, "validationpkg.json"); style == "additional" && document.Schema != draft07 {
+			t.Errorf("%s: expected the draft-07 dialect, got %s", style, document.Schema)
+		}
+	}
+}
+
+func TestDefinedFromStruct(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Renaming", []validationCase{
+		{"fields of the underlying struct", `{"renamed": {"name": "a"}}`, true},
+		{"missing field of the underlying struct", `{"renamed": {}}`, false},
+		{"invalid field of the underlying struct", `{"renamed": {"name": 1}}`, false},
+	})
+}
+
+func TestPointerEmbed(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/validationpkg")
+	derived := unmarshalDocument(t, documents, "validationpkg.json").Definitions["PointerDerived"]
+	if len(derived.AllOf) != 1 || derived.AllOf[0].Ref == nil || *derived.AllOf[0].Ref != "#/definitions/Base" {
+		t.Fatalf("expected the pointer embed to reference Base, got %+v", derived.AllOf)
+	}
+	if _, isProperty := derived.Properties["Base"]; isProperty {
+		t.Errorf("unexpected property for the pointer embed %+v", derived.Properties)
+	}
+	validateDefinition(t, "validationpkg.json", "PointerDerived", []validationCase{
+		{"base properties", `{"name": "a", "size": 1}`, true},
+		{"missing base property", `{"size": 1}`, false},
+		{"invalid base property", `{"name": 1, "size": 1}`, false},
+	})
+}
+
+func TestDefinitionsRoot(t *testing.T) {
+	for _, key := range []string{"$defs", "schemas"} {
+		documents := generateInMemory(t, Generator{DefinitionsRoot: key}, LoadOptions{},
+			"../../testPkgs/sharedpkg", "../../testPkgs/consumerpkg", "../../testPkgs/validationpkg")
+		for name, document := range documents {
+			if bytes.Contains(document.Bytes(), []byte(`"definitions"`)) || bytes.Contains(document.Bytes(), []byte("#/definitions/")) {
+				t.Errorf("%s: unexpected definitions in %s", key, name)
+			}
+		}
+		validateInMemory(t, documents, "validationpkg.json#/"+key+"/Derived", []validationCase{
+			{"missing inherited property", `{"size": 1}`, false},
+		})
+		validateInMemory(t, documents, "consumerpkg.json#/"+key+"/Consumer", []validationCase{
+			{"invalid shared label", `{"owner": {"name": "a", "labels": {}}, "labels": {"a": 1}}`, false},
+		})
+	}
+}
+
+func TestAllOfOrder(t *testing.T) {
+	expected := []string{"#/definitions/Versioned", "#/definitions/Base", "#/definitions/Labeled"}
+	for _, generator := range []Generator{{}, {ClosedStyle: "unevaluated", CleanRefs: true}} {
+		for run := 0; run < 3; run++ {
+			documents := generateInMemory(t, generator, LoadOptions{}, "../../testPkgs/validationpkg")
+			allOf := unmarshalDocument(t, documents, "validationpkg.json").Definitions["MultiDerived"].AllOf
+			refs := []string{}
+			for _, member := range allOf {
+				if member.Ref != nil {
+					refs = append(refs, *member.Ref)
+				}
+			}
+			if !reflect.DeepEqual(refs, expected) {
+				t.Errorf("run %d of %+v: unexpected allOf order %v", run, generator, refs)
+			}
+		}
+	}
+	validateDefinition(t, "validationpkg.json", "MultiDerived", []validationCase{
+		{"all bases", `{"version": 1, "name": "a", "label": "b", "size": 2}`, true},
+		{"missing base property", `{"version": 1, "label": "b", "size": 2}`, false},
+	})
+}
+
+func TestUnsupportedClosedStyle(t *testing.T) {
+	_, _, err := generateWithErrors(t, Generator{ClosedStyle: "strict"}, LoadOptions{}, "../../testPkgs/externalpkg")
+	if err == nil || !strings.Contains(err.Error(), "unsupported closed style strict") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestEmptyStruct(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "Flags", []validationCase{
+		{"empty objects", `{"marker": {}, "anon": {}, "set": {"a": {}}}`, true},
+		{"any objects", `{"marker": {"a": 1}, "anon": {"b": 2}, "set": {"a": {"c": 3}}}`, true},
+		{"non-object", `{"marker": 1, "anon": {}, "set": {}}`, false},
+	})
+
+	documents := generateInMemory(t, Generator{EmptyStruct: "closed"}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/Flags", []validationCase{
+		{"empty objects", `{"marker": {}, "anon": {}, "set": {"a": {}}}`, true},
+		{"named struct property", `{"marker": {"a": 1}, "anon": {}, "set": {}}`, false},
+		{"anonymous struct property", `{"marker": {}, "anon": {"b": 2}, "set": {}}`, false},
+		{"set value property", `{"marker": {}, "anon": {}, "set": {"a": {"c": 3}}}`, false},
+	})
+
+	_, _, err := generateWithErrors(t, Generator{EmptyStruct: "strict"}, LoadOptions{}, "../../testPkgs/externalpkg")
+	if err == nil || !strings.Contains(err.Error(), "unsupported empty struct handling strict") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCleanRefs(t *testing.T) {
+	documents := generateInMemory(t, Generator{CleanRefs: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	document := unmarshalDocument(t, documents, "validationpkg.json")
+	walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref == nil {
+			return
+		}
+		siblings := *subschema
+		siblings.Ref = nil
+		if !reflect.DeepEqual(siblings, apiext.JSONSchemaProps{}) {
+			t.Errorf("unexpected siblings of %s: %+v", *subschema.Ref, siblings)
+		}
+	})
+	priority := document.Definitions["Prioritized"].Properties["priority"]
+	if priority.Description != "The priority of the request" || len(priority.AllOf) != 1 || priority.AllOf[0].Ref == nil {
+		t.Errorf("unexpected wrapped reference %+v", priority)
+	}
+	validateDefinition(t, "validationpkg.json", "Prioritized", []validationCase{
+		{"valid", `{"priority": 1}`, true},
+		{"invalid", `{"priority": "high"}`, false},
+	})
+}
+
+func TestJSONEnum(t *testing.T) {
+	validateDefinition(t, "validationpkg.json", "SeparatedEnums", []validationCase{
+		{"value with semicolon", `{"separated": "a;b", "number": 10}`, true},
+		{"value with comma", `{"separated": "c,d", "number": 20}`, true},
+		{"split value", `{"separated": "a", "number": 10}`, false},
+		{"number not in enum", `{"separated": "a;b", "number": 15}`, false},
+	})
+}
+
+func TestUnresolvedRoot(t *testing.T) {
+	t.Setenv("GOPROXY", "off")
+	root := "example.com/does/not/exist"
+	_, runtime, err := generateWithErrors(t, Generator{}, LoadOptions{}, root)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	for _, err := range runtime.Roots[0].Errors {
+		if strings.Contains(err.Error(), "cannot resolve package root "+root) && strings.Contains(err.Error(), "go get "+root) {
+			return
+		}
+	}
+	t.Errorf("missing actionable error in %v", runtime.Roots[0].Errors)
+}
+
+func TestChangelog(t *testing.T) {
+	oldDocuments := map[string][]byte{
+		"a.json": []byte(`{"title": "a.json", "definitions": {
+			"T": {"type": "object", "required": ["name"], "properties": {
+				"name": {"type": "string", "maxLength": 5, "description": "The name"},
+				"tags": {"type": "array", "items": {"type": "string"}}}},
+			"Old": {"type": "object"}}}`),
+		"same.json": []byte(`{"title": "same.json", "definitions": {"S": {"type": "string"}}}`),
+		"gone.json": []byte(`{"title": "gone.json"}`),
+	}
+	newDocuments := map[string][]byte{
+		"a.json": []byte(`{"title": "a.json", "definitions": {
+			"T": {"type": "object", "required": ["size"], "properties": {
+				"name": {"type": "string", "maxLength": 10, "description": "The new name"},
+				"size": {"type": "integer"},
+				"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}}}},
+			"New": {"type": "object"}}}`),
+		"same.json": []byte(`{"title": "same.json", "definitions": {"S": {"type": "string", "description": "S"}}}`),
+		"new.json":  []byte(`{"title": "new.json"}`),
+	}
+	var changelog bytes.Buffer
+	if err := WriteChangelog(&changelog, oldDocuments, newDocuments); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	expected := "# Changelog\n" +
+		"\n## a.json\n\n" +
+		"- Added type `New`\n" +
+		"- Removed type `Old`\n" +
+		"- Made field `T.name` optional\n" +
+		"- Changed `maxLength` of `T.name` from `5` to `10`\n" +
+		"- Added required field `T.size`\n" +
+		"- Added `pattern: \"^[a-z]+$\"` to `T.tags[]`\n" +
+		"\n## gone.json\n\n- Removed document\n" +
+		"\n## new.json\n\n- Added document\n"
+	if changelog.String() != expected {
+		t.Errorf("unexpected changelog:\n%s", changelog.String())
+	}
+
+	changelog.Reset()
+	if err := WriteChangelog(&changelog, newDocuments, newDocuments); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if changelog.String() != "# Changelog\n\nNo changes\n" {
+		t.Errorf("unexpected changelog:\n%s", changelog.String())
+	}
+}
+
+func TestSummary(t *testing.T) {
+	var summary bytes.Buffer
+	generateInMemory(t, Generator{Summary: &summary}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	expected := `documents: 4
+  external.json: 4 definitions
+  optional_crd.json: 1 definitions
+  sample_crd.json: 1 definitions
+  schemapkg.json: 2 definitions
+object types: 2
+external types: 4
+  fybrik.io~1json-schema-generator~1testPkgs~1fybrikobject~0OptionalCrd
+  fybrik.io~1json-schema-generator~1testPkgs~1fybrikobject~0SampleCrd
+  fybrik.io~1json-schema-generator~1testPkgs~1fybrikobject~0Type1
+  fybrik.io~1json-schema-generator~1testPkgs~1fybrikobject~0Type2
+`
+	if summary.String() != expected {
+		t.Errorf("unexpected summary:\n%s", summary.String())
+	}
+}
+
+func TestSchemaDialect(t *testing.T) {
+	// Note: the draft-07 dialect is the default
+	for _, dialect := range []string{Empty, "draft-07"} {
+		documents := generateInMemory(t, Generator{SchemaDialect: dialect}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+		if document := unmarshalDocument(t, documents, "alpha.fybrik.io.json"); document.Schema != draft07 {
+			t.Errorf("dialect %q: expected the draft-07 dialect, got %s", dialect, document.Schema)
+		}
+	}
+
+	documents := generateInMemory(t, Generator{SchemaDialect: "2020-12"}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+	for name, document := range documents {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, document.Bytes()); err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		data := compacted.String()
+		if !strings.HasPrefix(data, `{"$schema":"`+draft202012+`"`) || strings.Count(data, `"$schema"`) != 1 {
+			t.Errorf("expected a single $schema at the top of %s: %s", name, data)
+		}
+		if strings.Contains(data, "definitions") {
+			t.Errorf("unexpected definitions in %s: %s", name, data)
+		}
+	}
+	if data := string(documents["beta.fybrik.io.json"].Bytes()); !strings.Contains(data, `"alpha.fybrik.io.json#/$defs/Price"`) {
+		t.Errorf("expected a reference to the $defs of alpha.fybrik.io.json in %s", data)
+	}
+
+	validateInMemory(t, documents, "alpha.fybrik.io.json#/$defs/Order", []validationCase{
+		{"valid order", `{"items": [{"name": "a", "price": {"amount": 1, "currency": "EUR"}}]}`, true},
+		{"negative price", `{"items": [{"name": "a", "price": {"amount": -1, "currency": "EUR"}}]}`, false},
+	})
+}
+
+func TestUnsupportedSchemaDialect(t *testing.T) {
+	for _, tt := range []struct {
+		generator Generator
+		err       string
+	}{
+		{Generator{SchemaDialect: "draft-04"}, "unsupported schema dialect draft-04"},
+		{Generator{SchemaDialect: "2020-12", RecursiveRefs: true}, "replaced $recursiveRef"},
+		{Generator{SchemaDialect: "2020-12", DefinitionsRoot: "components/schemas"}, "requires the $defs definitions root"},
+		{Generator{SchemaDialect: "2019-09", FormatAssertion: true}, "format assertion requires draft 2020-12"},
+		{Generator{SchemaDialect: "draft-07", RecursiveRefs: true}, "recursive refs require draft 2019-09, rather than the draft-07"},
+		{Generator{SchemaDialect: "draft-07", ClosedStyle: "unevaluated"}, "unevaluated closed style requires draft 2019-09"},
+		{Generator{SchemaDialect: "draft-07", FormatAssertion: true}, "format assertion requires draft 2020-12, rather than the draft-07"},
+	} {
+		_, _, err := generateWithErrors(t, tt.generator, LoadOptions{}, "../../testPkgs/externalpkg")
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("unexpected error %v, expected %s", err, tt.err)
+		}
+	}
+}
+
+func TestAssumeIntWidth(t *testing.T) {
+	for _, tt := range []struct {
+		width  int
+		format string
+	}{
+		{0, "int64"},
+		{64, "int64"},
+		{32, "int32"},
+	} {
+		documents := generateInMemory(t, Generator{AssumeIntWidth: tt.width}, LoadOptions{}, "../../testPkgs/validationpkg")
+		counters := unmarshalDocument(t, documents, "validationpkg.json").Definitions["Counters"]
+		for _, name := range []string{"count", "total"} {
+			if property := counters.Properties[name]; property.Type != "integer" || property.Format != tt.format {
+				t.Errorf("width %d: unexpected schema of %s %+v", tt.width, name, property)
+			}
+		}
+		if small := counters.Properties["small"]; small.Format != "int32" {
+			t.Errorf("width %d: unexpected format %s of a fixed width int", tt.width, small.Format)
+		}
+	}
+}
+
+func TestYAMLFormat(t *testing.T) {
+	roots := []string{"../../testPkgs/validationpkg", "../../testPkgs/cyclepkg/..."}
+	jsonDocuments := generateInMemory(t, Generator{}, LoadOptions{}, roots...)
+	yamlDocuments := generateInMemory(t, Generator{Format: "yaml"}, LoadOptions{}, roots...)
+	if len(yamlDocuments) != len(jsonDocuments) {
+		t.Errorf("expected %d YAML documents, got %d", len(jsonDocuments), len(yamlDocuments))
+	}
+	for name := range jsonDocuments {
+		yamlName := strings.TrimSuffix(name, ".json") + ".yaml"
+		document, exists := yamlDocuments[yamlName]
+		if !exists {
+			t.Errorf("missing document %s", yamlName)
+			continue
+		}
+		var parsed interface{}
+		if err := yaml.Unmarshal(document.Bytes(), &parsed); err != nil {
+			t.Fatalf("document %s: %v", yamlName, err)
+		}
+		data, err := json.Marshal(parsed)
+		if err != nil {
+			t.Fatalf("document %s: %v", yamlName, err)
+		}
+		// Note: the titles and references name the YAML documents
+		actual := &apiext.JSONSchemaProps{}
+		if err := json.Unmarshal([]byte(strings.ReplaceAll(string(data), ".yaml", ".json")), actual); err != nil {
+			t.Fatalf("document %s: %v", yamlName, err)
+		}
+		if expected := unmarshalDocument(t, jsonDocuments, name); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("document %s: expected %s, got %s", yamlName, expected, actual)
+		}
+	}
+}
+
+func TestHeaderComment(t *testing.T) {
+	header := "AUTO-GENERATED, DO NOT EDIT\n\nGenerated from cyclepkg"
+	expected := "# AUTO-GENERATED, DO NOT EDIT\n#\n# Generated from cyclepkg\n"
+	documents := generateInMemory(t, Generator{Format: "yaml", HeaderComment: header}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+	for name, document := range documents {
+		if data := document.String(); !strings.HasPrefix(data, expected) {
+			t.Errorf("missing header comment atop %s: %s", name, data)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal(document.Bytes(), &parsed); err != nil || parsed["title"] != name {
+			t.Errorf("document %s: unexpected title %v, error %v", name, parsed["title"], err)
+		}
+	}
+
+	documents = generateInMemory(t, Generator{HeaderComment: header}, LoadOptions{}, "../../testPkgs/cyclepkg/...")
+	unmarshalDocument(t, documents, "alpha.fybrik.io.json")
+}
+
+func TestGenerateSchemas(t *testing.T) {
+	documents, err := GenerateSchemas(Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	generated := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/fybrikobject")
+	if len(documents) != len(generated) {
+		t.Errorf("expected %d documents, got %d", len(generated), len(documents))
+	}
+	for name, document := range generated {
+		if string(documents[name]) != document.String() {
+			t.Errorf("document %s: expected %s, got %s", name, document.String(), documents[name])
+		}
+	}
+	var sampleCrd struct {
+		Title      string `json:"title"`
+		Properties map[string]struct {
+			Ref string `json:"$ref"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(documents["sample_crd.json"], &sampleCrd); err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if sampleCrd.Title != "sample_crd.json" || sampleCrd.Properties["field1"].Ref != "#/definitions/Type1" {
+		t.Errorf("unexpected document sample_crd.json %s", documents["sample_crd.json"])
+	}
+
+	// the errors of the packages are returned
+	_, err = GenerateSchemas(Generator{}, LoadOptions{}, "../../testPkgs/funcpkg")
+	if err == nil || !strings.Contains(err.Error(), `unsupported type func() of field "Routes" of type "Router"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPropagateRequired(t *testing.T) {
+	documents := generateInMemory(t, Generator{PropagateRequired: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	definitions := unmarshalDocument(t, documents, "validationpkg.json").Definitions
+	for name, expected := range map[string][]string{
+		"Derived":      {"size", "name"},
+		"MultiDerived": {"size", "version", "name", "label"},
+		"Base":         {"name"},
+	} {
+		if required := definitions[name].Required; !reflect.DeepEqual(required, expected) {
+			t.Errorf("%s: expected required %v, got %v", name, expected, required)
+		}
+	}
+
+	// a validator that doesn't evaluate allOf still rejects the payloads that miss inherited properties
+	derived := definitions["Derived"]
+	if len(derived.AllOf) != 1 {
+		t.Fatalf("expected the embedded base to be kept, got %v", derived.AllOf)
+	}
+	derived.AllOf = nil
+	data, err := json.Marshal(derived)
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	document := &memoryDocument{}
+	document.Write(data)
+	validateInMemory(t, map[string]*memoryDocument{"derived.json": document}, "derived.json", []validationCase{
+		{"all properties", `{"name": "a", "size": 1}`, true},
+		{"missing inherited property", `{"size": 1}`, false},
+	})
+}
+
+func TestInferEnums(t *testing.T) {
+	allowDangerousTypes := true
+	documents := generateInMemory(t, Generator{AllowDangerousTypes: &allowDangerousTypes}, LoadOptions{}, "../../testPkgs/enumpkg")
+	definitions := unmarshalDocument(t, documents, "enumpkg.json").Definitions
+	for name, expected := range map[string]string{
+		"Protocol": `["http","https","grpc"]`,
+		"Stage":    `[1,2,3]`,
+		"Weight":   `[0.5,2]`,
+		"Quota":    `[0,18446744073709551615]`,
+		// bit flags may be combined, so they are left open
+		"Perm": `null`,
+		"Mask": `null`,
+	} {
+		enum, err := json.Marshal(definitions[name].Enum)
+		if err != nil {
+			t.Fatalf("error %v\n", err)
+		}
+		if string(enum) != expected {
+			t.Errorf("%s: expected enum %s, got %s", name, expected, enum)
+		}
+	}
+	if enum := definitions["Hostname"].Enum; enum != nil {
+		t.Errorf("unexpected enum %v of a type without constants", enum)
+	}
+	if ref := definitions["Endpoint"].Properties["protocol"].Ref; ref == nil || *ref != "#/definitions/Protocol" {
+		t.Errorf("unexpected reference %v to the enum", ref)
+	}
+	validateInMemory(t, documents, "enumpkg.json#/definitions/Endpoint", []validationCase{
+		{"valid endpoint", `{"protocol": "https", "stage": 2, "weight": 0.5, "host": "a"}`, true},
+		{"unknown protocol", `{"protocol": "ftp", "stage": 2, "weight": 0.5, "host": "a"}`, false},
+		{"unknown stage", `{"protocol": "https", "stage": 0, "weight": 0.5, "host": "a"}`, false},
+		{"combined perm", `{"protocol": "https", "stage": 2, "weight": 0.5, "host": "a", "perm": 3}`, true},
+	})
+
+	// float constants follow the allowDangerousTypes gate
+	documents = generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/enumpkg")
+	if enum := unmarshalDocument(t, documents, "enumpkg.json").Definitions["Weight"].Enum; enum != nil {
+		t.Errorf("unexpected enum %v of a float type", enum)
+	}
+
+	inferEnums := false
+	documents = generateInMemory(t, Generator{InferEnums: &inferEnums}, LoadOptions{}, "../../testPkgs/enumpkg")
+	if enum := unmarshalDocument(t, documents, "enumpkg.json").Definitions["Protocol"].Enum; enum != nil {
+		t.Errorf("unexpected enum %v with the inference disabled", enum)
+	}
+}
+
+func TestPointerToSlice(t *testing.T) {
+	for _, nullablePointers := range []bool{false, true} {
+		documents := generateInMemory(t, Generator{NullablePointers: nullablePointers}, LoadOptions{}, "../../testPkgs/validationpkg")
+		points := unmarshalDocument(t, documents, "validationpkg.json").Definitions["PointerSlice"].Properties["points"]
+		array := points
+		if nullablePointers {
+			if len(points.AnyOf) != 2 || points.AnyOf[1].Type != "null" {
+				t.Fatalf("expected a nullable array, got %+v", points)
+			}
+			array = points.AnyOf[0]
+		}
+		if array.Type != "array" || array.Items == nil || array.Items.Schema == nil || array.Items.Schema.Ref == nil ||
+			*array.Items.Schema.Ref != "#/definitions/Point" {
+			t.Errorf("nullable pointers %v: expected an array of Point, got %+v", nullablePointers, points)
+		}
+	}
+
+	runtime, err := ForRoots(genall.Generators{}, LoadOptions{}, "../../testPkgs/validationpkg")
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	pkg := runtime.Roots[0]
+	pkg.NeedTypesInfo()
+	var fieldType ast.Expr
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if typeSpec, isTypeSpec := node.(*ast.TypeSpec); isTypeSpec && typeSpec.Name.Name == "PointerSlice" {
+				fieldType = typeSpec.Type.(*ast.StructType).Fields.List[0].Type
+			}
+			return fieldType == nil
+		})
+	}
+	if typeIdent := typeToTypeIdent(fieldType, pkg); typeIdent.Package != pkg || typeIdent.Name != "Point" {
+		t.Errorf("expected the type ident of Point, got %v", typeIdent)
+	}
+}
+
+func TestNullablePointerFields(t *testing.T) {
+	expected := map[string]string{
+		"name":   `{"type":"string"}`,
+		"point":  `{"$ref":"#/definitions/Point"}`,
+		"counts": `{"type":"array","items":{"type":"integer","format":"int64"}}`,
+		"plain":  `{"type":"string"}`,
+	}
+	for _, nullablePointers := range []bool{false, true} {
+		documents := generateInMemory(t, Generator{NullablePointers: nullablePointers}, LoadOptions{}, "../../testPkgs/validationpkg")
+		properties := unmarshalDocument(t, documents, "validationpkg.json").Definitions["PointerFields"].Properties
+		for name, schema := range expected {
+			if nullablePointers && name != "plain" {
+				schema = `{"anyOf":[` + schema + `,{"type":"null"}]}`
+			}
+			property := properties[name]
+			if data, err := json.Marshal(&property); err != nil || string(data) != schema {
+				t.Errorf("nullable pointers %v: expected the schema %s of %s, got %s", nullablePointers, schema, name, data)
+			}
+		}
+	}
+
+	documents := generateInMemory(t, Generator{NullablePointers: true}, LoadOptions{}, "../../testPkgs/validationpkg")
+	validateInMemory(t, documents, "validationpkg.json#/definitions/PointerFields", []validationCase{
+		{"values", `{"name": "a", "point": {"coordinates": [1, 2, 3]}, "counts": [1], "plain": "b"}`, true},
+		{"nulls", `{"name": null, "point": null, "counts": null, "plain": "b"}`, true},
+		{"null non-pointer", `{"name": null, "point": null, "counts": null, "plain": null}`, false},
+	})
+}
+
+func TestValidateDocuments(t *testing.T) {
+	allowDangerousTypes := true
+	if _, err := GenerateSchemas(Generator{Validate: true, AllowDangerousTypes: &allowDangerousTypes}, LoadOptions{},
+		validTestRoots(t)...); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	_, err := GenerateSchemas(Generator{Validate: true}, LoadOptions{}, "../../testPkgs/lengthpkg")
+	if err == nil || !strings.Contains(err.Error(), "document length.json: ") {
+		t.Errorf("expected the compile error of length.json, got %v", err)
+	}
+	if _, err := GenerateSchemas(Generator{}, LoadOptions{}, "../../testPkgs/lengthpkg"); err != nil {
+		t.Errorf("unexpected error %v without validation", err)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	documents := generateInMemory(t, Generator{OpenAPI: true}, LoadOptions{},
+		"../../testPkgs/fybrikobject", "../../testPkgs/validationpkg", "../../testPkgs/cyclepkg/...")
+	if len(documents) != 1 || documents["openapi.json"] == nil {
+		t.Fatalf("expected a single openapi.json document, got %d documents", len(documents))
+	}
+	// Note: the document is loaded with an OpenAPI loader, which indexes and resolves the references of the components
+	document, err := libopenapi.NewDocument(documents["openapi.json"].Bytes())
+	if err != nil {
+		t.Fatalf("error %v\n", err)
+	}
+	if version := document.GetVersion(); !strings.HasPrefix(version, "3.1") {
+		t.Errorf("expected an OpenAPI 3.1 document, got %s", version)
+	}
+	model, errs := document.BuildV3Model()
+	for _, err := range errs {
+		// the cycles of the types are references to components which are being resolved, rather than unresolved references
+		if resolvingErr, isResolvingErr := err.(*resolver.ResolvingError); !isResolvingErr || resolvingErr.CircularReference == nil {
+			t.Errorf("error %v\n", err)
+		}
+	}
+	if model == nil {
+		t.FailNow()
+	}
+	if info := model.Model.Info; info == nil || info.Title == Empty || info.Version == Empty {
+		t.Errorf("expected an OpenAPI document with info, got %v", info)
+	}
+	schemas := model.Model.Components.Schemas
+	for _, key := range []string{"sample_crd", "optional_crd", "split_part", "alpha.fybrik.io.Order", "validationpkg.Level",
+		"fybrik.io.json-schema-generator.testPkgs.fybrikobject.Type1", "encoding.json.Number"} {
+		if _, exists := schemas[key]; !exists {
+			t.Errorf("expected the component %s", key)
+		}
+	}
+	componentKey := regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	for key, schema := range schemas {
+		if !componentKey.MatchString(key) {
+			t.Errorf("invalid component key %s", key)
+		}
+		if _, err := schema.BuildSchema(); err != nil {
+			t.Errorf("component %s: error %v\n", key, err)
+		}
+	}
+	for _, err := range model.Index.GetReferenceIndexErrors() {
+		t.Errorf("error %v\n", err)
+	}
+	mappedRefs := model.Index.GetMappedReferences()
+	if len(model.Index.GetAllReferences()) == 0 {
+		t.Errorf("expected references between the components")
+	}
+	for ref := range model.Index.GetAllReferences() {
+		if _, resolved := mappedRefs[ref]; !resolved || !strings.HasPrefix(ref, "#/components/schemas/") {
+			t.Errorf("unresolved reference %s", ref)
+		}
+	}
+
+	validateInMemory(t, documents, "openapi.json#/components/schemas/split_part", []validationCase{
+		{"valid part", `{"name": "a", "level": 2}`, true},
+		{"unknown level", `{"name": "a", "level": 5}`, false},
+	})
+	compileInMemory(t, documents, "openapi.json#/components/schemas/sample_crd")
+}
+
+func TestUnsupportedOpenAPI(t *testing.T) {
+	for _, tt := range []struct {
+		generator Generator
+		err       string
+	}{
+		{Generator{OpenAPI: true, RecursiveRefs: true}, "recursive refs are not supported with the OpenAPI option"},
+		{Generator{OpenAPI: true, FormatAssertion: true}, "format assertion is not supported with the OpenAPI option"},
+		{Generator{OpenAPI: true, EmitManifest: true}, "the manifest is not supported with the OpenAPI option"},
+	} {
+		_, _, err := generateWithErrors(t, tt.generator, LoadOptions{}, "../../testPkgs/externalpkg")
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("unexpected error %v, expected %s", err, tt.err)
+		}
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	_, err := GenerateSchemas(Generator{}, LoadOptions{}, "../../testPkgs/patternpkg")
+	if err == nil || !strings.Contains(err.Error(), `field "Name" of type "Patterned" has an invalid pattern "^[a-z"`) {
+		t.Errorf("expected the invalid pattern of the Name field, got %v", err)
+	}
+}
+
+func TestGroupByObject(t *testing.T) {
+	documents := generateInMemory(t, Generator{GroupByObject: true}, LoadOptions{}, "../../testPkgs/grouppkg")
+	names := []string{}
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"address.json", "grouppkg.json", "invoice/grouppkg.json", "invoice/invoice.json",
+		"order/address.json", "order/grouppkg.json", "order/order.json"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected the documents %v, got %v", expected, names)
+	}
+	for name, definitions := range map[string][]string{
+		"order/grouppkg.json":   {"Amount", "Item"},
+		"invoice/grouppkg.json": {"Amount"},
+	} {
+		if document := unmarshalDocument(t, documents, name); !reflect.DeepEqual(sortedKeys(document.Definitions, nil), definitions) {
+			t.Errorf("expected the definitions %v in %s, got %v", definitions, name, sortedKeys(document.Definitions, nil))
+		}
+	}
+
+	for _, object := range []string{"order", "invoice"} {
+		// the directory of the object is compiled on its own, so that its references must resolve within it
+		bundle := map[string]*memoryDocument{}
+		for name, document := range documents {
+			if !strings.HasPrefix(name, object+"/") {
+				continue
+			}
+			walkSchema(unmarshalDocument(t, documents, name), func(subschema *apiext.JSONSchemaProps) {
+				if subschema.Ref == nil {
+					return
+				}
+				if documentPart, _, _ := strings.Cut(*subschema.Ref, "#"); documentPart != Empty && documents[object+"/"+documentPart] == nil {
+					t.Errorf("reference %s of %s doesn't resolve within %s", *subschema.Ref, name, object)
+				}
+			})
+			bundle[name] = document
+		}
+		compileInMemory(t, bundle, object+"/"+object+".json")
+	}
+
+	_, err := GenerateSchemas(Generator{GroupByObject: true, EmitManifest: true}, LoadOptions{}, "../../testPkgs/grouppkg")
+	if err == nil || !strings.Contains(err.Error(), "can't be combined with the OpenAPI option or the manifest") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestOneOfImplementations(t *testing.T) {
+	documents := generateInMemory(t, Generator{}, LoadOptions{}, "../../testPkgs/pluginpkg/...")
+	config := unmarshalDocument(t, documents, "pluginpkg.json").Definitions["Config"]
+	target := config.Properties["target"]
+	if len(target.OneOf) != 2 || *target.OneOf[0].Ref != "#/definitions/Local" || *target.OneOf[1].Ref != "remote.json#/definitions/Remote" {
+		t.Errorf("expected the target to be one of Local and Remote, got %v", target)
+	}
+
+	validateInMemory(t, documents, "pluginpkg.json#/definitions/Config", []validationCase{
+		{"local plugin and remote target", `{"plugin": {"path": "/a"}, "target": {"url": "https://b"}}`, true},
+		{"remote plugin and local target", `{"plugin": {"url": "https://a"}, "target": {"path": "/b"}}`, true},
+		{"invalid remote target", `{"plugin": {"path": "/a"}, "target": {"url": "http://b"}}`, false},
+		{"no implementation", `{"plugin": {"name": "a"}, "target": {"path": "/b"}}`, false},
+	})
+
+	// the package of the remote implementation isn't imported, so it must be loaded as a root
+	_, err := GenerateSchemas(Generator{}, LoadOptions{}, "../../testPkgs/pluginpkg")
+	if err == nil || !strings.Contains(err.Error(), "of a package that isn't loaded") {
+		t.Errorf("expected an error for the package that isn't loaded, got %v", err)
+	}
+}
+
+func TestNestedMapValues(t *testing.T) {
+	// Tag is only referenced by the values of the values of a map, so it's discovered through them
+	allowDangerousTypes := true
+	documents := generateInMemory(t, Generator{AllowDangerousTypes: &allowDangerousTypes}, LoadOptions{}, "../../testPkgs/validationpkg")
+	tagName := qualifiedName("fybrik.io/json-schema-generator/testPkgs/externalpkg", "Tag")
+	if _, exists := unmarshalDocument(t, documents, "external.json").Definitions[tagName]; !exists {
+		t.Fatalf("expected the definition %s in external.json", tagName)
+	}
+	tags := unmarshalDocument(t, documents, "validationpkg.json").Definitions["NestedMaps"].Properties["tags"]
+	values := tags.AdditionalProperties.Schema.AdditionalProperties.Schema
+	if expected := "external.json#/definitions/" + escapeJSONPointer(tagName); values.Ref == nil || *values.Ref != expected {
+		t.Errorf("expected a reference to %s, got %v", expected, values)
+	}
+
+	validateInMemory(t, documents, "validationpkg.json#/definitions/NestedMaps", []validationCase{
+		{"tags", `{"tags": {"a": {"b": {"value": "x"}}}}`, true},
+		{"empty tag", `{"tags": {"a": {"b": {"value": ""}}}}`, false},
+		{"tag as a value", `{"tags": {"a": {"value": "x"}}}`, false},
+	})
+}
+
+func TestIDBase(t *testing.T) {
+	allowDangerousTypes := true
+	externalRef := "#/definitions/" + escapeJSONPointer(qualifiedName("fybrik.io/json-schema-generator/testPkgs/externalpkg", "ExternalType"))
+	for _, tt := range []struct {
+		idBase string
+		base   string
+	}{
+		{Empty, Empty},
+		{"https://schemas.example.com/v1/", "https://schemas.example.com/v1/"},
+		{"https://schemas.example.com/v1", "https://schemas.example.com/v1/"},
+	} {
+		documents := generateInMemory(t, Generator{IDBase: tt.idBase, AllowDangerousTypes: &allowDangerousTypes}, LoadOptions{},
+			"../../testPkgs/validationpkg")
+		validation := unmarshalDocument(t, documents, "validationpkg.json")
+		for _, documentName := range []string{"validationpkg.json", "external.json"} {
+			var document struct {
+				ID *string `json:"$id"`
+			}
+			if err := json.Unmarshal(documents[documentName].Bytes(), &document); err != nil {
+				t.Fatalf("document %s: %v", documentName, err)
+			}
+			if tt.base == Empty && document.ID != nil || tt.base != Empty && (document.ID == nil || *document.ID != tt.base+documentName) {
+				t.Errorf("unexpected $id %v of %s for the base %s", document.ID, documentName, tt.idBase)
+			}
+		}
+		if ref := validation.Definitions["ExternalRef"].Properties["field"].Ref; ref == nil || *ref != tt.base+"external.json"+externalRef {
+			t.Errorf("expected a reference to %s, got %v", tt.base+"external.json"+externalRef, ref)
+		}
+		// references within a document stay relative
+		if ref := validation.Definitions["Drawing"].Properties["shapes"].AdditionalProperties.Schema.Ref; ref == nil ||
+			*ref != "#/definitions/Shape" {
+			t.Errorf("expected a relative reference to Shape, got %v", ref)
+		}
+		if tt.base == Empty {
+			continue
+		}
+
+		validateInMemory(t, documents, tt.base+"validationpkg.json#/definitions/ExternalRef", []validationCase{
+			{"negative count", `{"field": {"count": -1}}`, false},
+		})
+	}
+}
+
+func TestPropertyCase(t *testing.T) {
+	for _, tt := range []struct {
+		style      string
+		properties []string
+		required   []string
+		oneOf      []string
+		valid      string
+	}{
+		{camelPropertyCase, []string{"accessKey", "maxRetries", "region", "retryDelay", "secretRef"}, []string{"maxRetries", "region"},
+			[]string{"accessKey", "secretRef"}, `{"accessKey": "a", "maxRetries": 1, "region": "eu"}`},
+		{snakePropertyCase, []string{"access_key", "max_retries", "region", "retry_delay", "secret_ref"}, []string{"max_retries", "region"},
+			[]string{"access_key", "secret_ref"}, `{"secret_ref": "s", "max_retries": 1, "region": "eu", "retry_delay": 2}`},
+	} {
+		documents := generateInMemory(t, Generator{PropertyCase: tt.style}, LoadOptions{}, "../../testPkgs/validationpkg")
+		storeAccess := unmarshalDocument(t, documents, "validationpkg.json").Definitions["StoreAccess"]
+		if properties := sortedKeys(storeAccess.Properties, nil); !reflect.DeepEqual(properties, tt.properties) {
+			t.Errorf("%s: expected properties %v, got %v", tt.style, tt.properties, properties)
+		}
+		required := append([]string{}, storeAccess.Required...)
+		sort.Strings(required)
+		if !reflect.DeepEqual(required, tt.required) {
+			t.Errorf("%s: expected required %v, got %v", tt.style, tt.required, required)
+		}
+		// each required name matches a renamed property, including the alternatives of exactlyOneOf
+		oneOf := []string{}
+		for _, alternative := range storeAccess.OneOf {
+			oneOf = append(oneOf, alternative.Required...)
+		}
+		sort.Strings(oneOf)
+		if !reflect.DeepEqual(oneOf, tt.oneOf) {
+			t.Errorf("%s: expected the alternatives to require %v, got %v", tt.style, tt.oneOf, oneOf)
+		}
+		for _, name := range append(required, oneOf...) {
+			if _, exists := storeAccess.Properties[name]; !exists {
+				t.Errorf("%s: required %s isn't a property", tt.style, name)
+			}
+		}
+
+		validateInMemory(t, documents, "validationpkg.json#/definitions/StoreAccess", []validationCase{{"renamed", tt.valid, true}})
+	}
+}
+
+func TestUnsupportedPropertyCase(t *testing.T) {
+	_, _, err := generateWithErrors(t, Generator{PropertyCase: "pascal"}, LoadOptions{}, "../../testPkgs/externalpkg")
+	if err == nil || !strings.Contains(err.Error(), "unsupported property case pascal") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPropertyWords(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"max_retries":   {"maxRetries", "max_retries"},
+		"maxRetries":    {"maxRetries", "max_retries"},
+		"HTTPServerURL": {"httpServerUrl", "http_server_url"},
+		"ipv4-address":  {"ipv4Address", "ipv4_address"},
+		"region":        {"region", "region"},
+	} {
+		if camel, snake := toCamelCase(name), toSnakeCase(name); camel != expected[0] || snake != expected[1] {
+			t.Errorf("%s: expected %v, got %s and %s", name, expected, camel, snake)
+		}
+	}
+}
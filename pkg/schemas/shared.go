@@ -0,0 +1,46 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// sharedDocumentBase is the name of the document of the types with the sharedDef marker
+const sharedDocumentBase = "common"
+
+// sharedDocumentName returns the name of the document of the types with the sharedDef marker
+func (context *GeneratorContext) sharedDocumentName() string {
+	return sharedDocumentBase + context.extension
+}
+
+// documentNameForType returns the name of the document that defines the given type: the shared
+// document for types with the sharedDef marker, and otherwise the document of its package
+func (context *GeneratorContext) documentNameForType(typ crd.TypeIdent) string {
+	if info, knownInfo := context.parser.Types[typ]; knownInfo && info.Markers.Get(sharedDefMarker.Name) != nil {
+		return context.sharedDocumentName()
+	}
+	return context.documentNameFor(typ.Package)
+}
+
+// homeDocumentRef returns the document that local references of the types of a package are relative to,
+// as it is referenced from other documents
+func (context *GeneratorContext) homeDocumentRef(pkg *loader.Package) string {
+	return context.documentURI(context.documentNameFor(pkg))
+}
+
+// prefixLocalRefs prefixes the local references of a schema, which is moved out of the given
+// home document, with the home document
+func prefixLocalRefs(schema *apiext.JSONSchemaProps, homeDocument string) {
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref != nil && strings.HasPrefix(*subschema.Ref, "#") {
+			ref := homeDocument + *subschema.Ref
+			subschema.Ref = &ref
+		}
+	})
+}
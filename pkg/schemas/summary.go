@@ -0,0 +1,65 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// summary counts what a generation run produced
+type summary struct {
+	// Names of the generated documents, sorted
+	documentNames []string
+	// Number of definitions per document name
+	definitions map[string]int
+	// Number of types with the object marker
+	objectTypes int
+	// Definition names of the types that were routed to the external document, sorted
+	externalTypes []string
+}
+
+func newSummary(documents map[string]*apiext.JSONSchemaProps, objectTypes int, externalDocumentName string) *summary {
+	s := &summary{
+		documentNames: []string{},
+		definitions:   make(map[string]int),
+		objectTypes:   objectTypes,
+		externalTypes: []string{},
+	}
+	for documentName, document := range documents {
+		s.documentNames = append(s.documentNames, documentName)
+		s.definitions[documentName] = len(document.Definitions)
+	}
+	sort.Strings(s.documentNames)
+	if external, exists := documents[externalDocumentName]; exists {
+		for definitionName := range external.Definitions {
+			s.externalTypes = append(s.externalTypes, definitionName)
+		}
+		sort.Strings(s.externalTypes)
+	}
+	return s
+}
+
+func (s *summary) write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "documents: %d\n", len(s.documentNames)); err != nil {
+		return err
+	}
+	for _, documentName := range s.documentNames {
+		if _, err := fmt.Fprintf(w, "  %s: %d definitions\n", documentName, s.definitions[documentName]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "object types: %d\nexternal types: %d\n", s.objectTypes, len(s.externalTypes)); err != nil {
+		return err
+	}
+	for _, externalType := range s.externalTypes {
+		if _, err := fmt.Fprintf(w, "  %s\n", externalType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// versionFor returns the API version of a package as set by the kubebuilder `versionName` marker.
+// Note: packages named after a version, such as v1, aren't versioned without the marker, so that the
+// documents of the usual kubebuilder layout keep their names
+func (context *GeneratorContext) versionFor(pkg *loader.Package) string {
+	if version, isSet := context.pkgMarkers[pkg].Get("versionName").(string); isSet {
+		return version
+	}
+	return Empty
+}
+
+// versionedName appends the API version of a package to the base name of its document, e.g., `widget.v1`,
+// unless the base name is the version itself
+func (context *GeneratorContext) versionedName(base string, pkg *loader.Package) string {
+	if version := context.versionFor(pkg); version != Empty && version != base {
+		return base + "." + version
+	}
+	return base
+}
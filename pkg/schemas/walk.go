@@ -0,0 +1,91 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"reflect"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// walkSchema calls visit for the schema and then for each of its subschemas, recursively.
+// Changes that visit makes to a subschema are kept.
+//
+//nolint:gocyclo
+func walkSchema(schema *apiext.JSONSchemaProps, visit func(*apiext.JSONSchemaProps)) {
+	visit(schema)
+
+	walkMap := func(schemas map[string]apiext.JSONSchemaProps) {
+		for name := range schemas {
+			// Note: keyword carriers aren't subschemas, their defaults hold the values of the keywords
+			if strings.HasPrefix(name, keywordPrefix) {
+				continue
+			}
+			subschema := schemas[name]
+			walkSchema(&subschema, visit)
+			schemas[name] = subschema
+		}
+	}
+	walkSlice := func(schemas []apiext.JSONSchemaProps) {
+		for i := range schemas {
+			walkSchema(&schemas[i], visit)
+		}
+	}
+
+	walkMap(schema.Properties)
+	walkMap(schema.PatternProperties)
+	walkMap(schema.Definitions)
+	walkSlice(schema.AllOf)
+	walkSlice(schema.OneOf)
+	walkSlice(schema.AnyOf)
+	if schema.Not != nil {
+		walkSchema(schema.Not, visit)
+	}
+	if schema.Items != nil {
+		if schema.Items.Schema != nil {
+			walkSchema(schema.Items.Schema, visit)
+		}
+		walkSlice(schema.Items.JSONSchemas)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		walkSchema(schema.AdditionalProperties.Schema, visit)
+	}
+	if schema.AdditionalItems != nil && schema.AdditionalItems.Schema != nil {
+		walkSchema(schema.AdditionalItems.Schema, visit)
+	}
+	for name, dependency := range schema.Dependencies {
+		if dependency.Schema != nil {
+			walkSchema(dependency.Schema, visit)
+			schema.Dependencies[name] = dependency
+		}
+	}
+}
+
+// wrapRefs moves the `$ref` of each schema that has sibling keywords into an allOf, since sibling
+// keywords of `$ref` are ignored by drafts before 2019-09
+func wrapRefs(schema *apiext.JSONSchemaProps) {
+	walkSchema(schema, func(subschema *apiext.JSONSchemaProps) {
+		if subschema.Ref == nil {
+			return
+		}
+		siblings := *subschema
+		siblings.Ref = nil
+		if reflect.DeepEqual(siblings, apiext.JSONSchemaProps{}) {
+			return
+		}
+		subschema.AllOf = append([]apiext.JSONSchemaProps{{Ref: subschema.Ref}}, subschema.AllOf...)
+		subschema.Ref = nil
+	})
+}
+
+// stripNestedDialects clears the `$schema` of the subschemas of a document, since only the root of a
+// document may declare its dialect
+func stripNestedDialects(document *apiext.JSONSchemaProps) {
+	dialect := document.Schema
+	walkSchema(document, func(subschema *apiext.JSONSchemaProps) {
+		subschema.Schema = Empty
+	})
+	document.Schema = dialect
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	jsonFormat = "json"
+	yamlFormat = "yaml"
+
+	// yamlExtension is the default extension of the document names in the YAML format
+	yamlExtension = ".yaml"
+)
+
+// jsonToYAML converts a JSON document to YAML, keeping the order of its keys.  JSON is YAML, so the document
+// is parsed as YAML, and the flow and quoting styles of the JSON syntax are cleared to encode it in block style.
+// The encoder still quotes the strings that would otherwise be read as other values, such as "true" or "1".
+func jsonToYAML(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	clearStyles(&document)
+	var encoded bytes.Buffer
+	encoder := yaml.NewEncoder(&encoded)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// clearStyles clears the styles of a node and of its descendants
+func clearStyles(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyles(child)
+	}
+}
+
+// yamlComment returns a comment block of the lines of text, each prefixed with `#`
+func yamlComment(text string) []byte {
+	var comment bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		comment.WriteString(strings.TrimRight("# "+line, " "))
+		comment.WriteByte('\n')
+	}
+	return comment.Bytes()
+}
@@ -0,0 +1,7 @@
+//go:build schemagen
+
+package buildtagpkg
+
+type TaggedType struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,5 @@
+package buildtagpkg
+
+type UntaggedType struct {
+	Name string `json:"name"`
+}
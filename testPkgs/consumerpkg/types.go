@@ -0,0 +1,13 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package consumerpkg holds sample types referencing a type with the sharedDef marker of another package.
+// +fybrik:validation:schema
+package consumerpkg
+
+import "fybrik.io/json-schema-generator/testPkgs/sharedpkg"
+
+type Consumer struct {
+	Owner  sharedpkg.Owner  `json:"owner"`
+	Labels sharedpkg.Labels `json:"labels"`
+}
@@ -0,0 +1,7 @@
+package a
+
+import "fybrik.io/json-schema-generator/testPkgs/cyclepkg/b"
+
+type Order struct {
+	Items []b.Item `json:"items"`
+}
@@ -0,0 +1,7 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package b holds sample types of the beta group, which reference the alpha group.
+// +fybrik:validation:schema
+// +fybrik:validation:group=beta.fybrik.io
+package b
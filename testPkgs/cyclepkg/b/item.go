@@ -0,0 +1,8 @@
+package b
+
+import "fybrik.io/json-schema-generator/testPkgs/cyclepkg/c"
+
+type Item struct {
+	Name  string  `json:"name"`
+	Price c.Price `json:"price"`
+}
@@ -0,0 +1,7 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package c holds sample types of the alpha group, which reference the beta group.
+// +fybrik:validation:schema
+// +fybrik:validation:group=alpha.fybrik.io
+package c
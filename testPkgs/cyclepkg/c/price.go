@@ -0,0 +1,8 @@
+package c
+
+type Price struct {
+	// +kubebuilder:validation:Minimum=0
+	Amount int `json:"amount"`
+
+	Currency string `json:"currency"`
+}
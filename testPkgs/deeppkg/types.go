@@ -0,0 +1,23 @@
+// Package deeppkg holds a chain of five nested types.
+// +fybrik:validation:schema
+package deeppkg
+
+type Level1 struct {
+	Next Level2 `json:"next"`
+}
+
+type Level2 struct {
+	Next Level3 `json:"next"`
+}
+
+type Level3 struct {
+	Next Level4 `json:"next"`
+}
+
+type Level4 struct {
+	Next Level5 `json:"next"`
+}
+
+type Level5 struct {
+	Value string `json:"value"`
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package enumpkg holds sample types with closed value sets modeled as typed constants.
+// +fybrik:validation:schema
+package enumpkg
+
+type Protocol string
+
+const (
+	ProtocolHTTP  Protocol = "http"
+	ProtocolHTTPS Protocol = "https"
+	ProtocolGRPC  Protocol = "grpc"
+
+	// unexported constants aren't part of the value set
+	protocolDefault Protocol = ProtocolHTTP
+)
+
+// OrDefault returns the protocol, or the default protocol if it is empty
+func (p Protocol) OrDefault() Protocol {
+	if p == "" {
+		return protocolDefault
+	}
+	return p
+}
+
+type Stage int
+
+const (
+	StagePending Stage = iota + 1
+	StageRunning
+	StageDone
+)
+
+type Weight float64
+
+const (
+	WeightLight Weight = 0.5
+	WeightHeavy Weight = 2
+)
+
+// Perm is a set of bit flags, whose values may be combined, e.g., PermRead|PermWrite
+type Perm uint8
+
+const (
+	PermRead Perm = 1 << iota
+	PermWrite
+	PermExecute
+)
+
+// Mask is a set of bit flags with the full range of uint64
+type Mask uint64
+
+const (
+	MaskNone Mask = 0
+	MaskAll  Mask = 1<<64 - 1
+)
+
+// Quota has values beyond the range of int64
+type Quota uint64
+
+const (
+	QuotaNone      Quota = 0
+	QuotaUnlimited Quota = 18446744073709551615
+)
+
+// Hostname has no constants
+type Hostname string
+
+type Endpoint struct {
+	Protocol Protocol `json:"protocol"`
+	Stage    Stage    `json:"stage"`
+	Weight   Weight   `json:"weight"`
+	Host     Hostname `json:"host"`
+	Perm     Perm     `json:"perm,omitempty"`
+	Mask     Mask     `json:"mask,omitempty"`
+	Quota    Quota    `json:"quota,omitempty"`
+}
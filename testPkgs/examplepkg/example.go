@@ -0,0 +1,15 @@
+// Package examplepkg holds types with examples that violate their schemas.
+// +fybrik:validation:schema
+package examplepkg
+
+type Server struct {
+	// +kubebuilder:validation:Minimum=1
+	Port int `json:"port" example:"0"`
+
+	Host string `json:"host" example:"localhost"`
+
+	Mode Mode `json:"mode" example:"fast"`
+}
+
+// +kubebuilder:validation:Enum=slow;safe
+type Mode string
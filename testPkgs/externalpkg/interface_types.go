@@ -0,0 +1,5 @@
+package externalpkg
+
+type Named interface {
+	GetName() string
+}
@@ -0,0 +1,15 @@
+package externalpkg
+
+type ExternalType struct {
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count"`
+}
+
+// ID is a UUID of a library that isn't known to the generator
+type ID [16]byte
+
+// Tag is only referenced by the values of nested maps
+type Tag struct {
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value"`
+}
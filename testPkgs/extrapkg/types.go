@@ -0,0 +1,17 @@
+// Package extrapkg holds types with invalid extraValues markers.
+// +fybrik:validation:schema
+package extrapkg
+
+// +fybrik:validation:extraValues={"type": "string"}
+type Name string
+
+// +fybrik:validation:extraValues=[1]
+type Invalid struct {
+	Name string `json:"name"`
+}
+
+// +kubebuilder:pruning:PreserveUnknownFields
+// +fybrik:validation:extraValues={"type": "string"}
+type Preserved struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,11 @@
+// Package failfastpkg holds types with errors.
+// +fybrik:validation:schema
+package failfastpkg
+
+type FirstInvalid struct {
+	Untagged string
+}
+
+type SecondInvalid struct {
+	Untagged string
+}
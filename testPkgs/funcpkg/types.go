@@ -0,0 +1,13 @@
+// Package funcpkg holds types with fields of functions and channels, which encoding/json can't serialize.
+// +fybrik:validation:schema
+package funcpkg
+
+type Subscriber struct {
+	Topic  string       `json:"topic"`
+	Notify func(string) `json:"notify"`
+	Events chan string  `json:"events"`
+}
+
+type Router struct {
+	Routes map[string]func() `json:"routes"`
+}
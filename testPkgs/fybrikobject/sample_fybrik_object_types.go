@@ -9,6 +9,12 @@ type SampleCrd struct {
 	Field3 string `json:"field3"`
 }
 
+// +fybrik:validation:object={"name": "optional_crd", "title": "Optional CRD"}
+type OptionalCrd struct {
+	Field1 Type1  `json:"field1,omitempty"`
+	Field2 string `json:"field2"`
+}
+
 type Type1 struct {
 	Type1F1 schemapkg.SchemaType1 `json:"type1f1,omitempty"`
 	Type1F2 string                `json:"type1f2,omitempty"`
@@ -0,0 +1,17 @@
+// Package genericpkg holds generic types that can't be described.
+// +fybrik:validation:schema
+package genericpkg
+
+// +fybrik:validation:object="list"
+type List[T any] struct {
+	Items []T `json:"items"`
+}
+
+type Node[T any] struct {
+	Value T        `json:"value"`
+	Next  *Node[T] `json:"next,omitempty"`
+}
+
+type Tree struct {
+	Root Node[string] `json:"root"`
+}
@@ -0,0 +1,7 @@
+package groupa
+
+import "fybrik.io/json-schema-generator/testPkgs/groupb"
+
+type GroupTypeA struct {
+	FieldB groupb.GroupTypeB `json:"fieldB"`
+}
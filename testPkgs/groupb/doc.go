@@ -0,0 +1,6 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+// +fybrik:validation:group=group.fybrik.io
+package groupb
@@ -0,0 +1,6 @@
+package groupb
+
+type GroupTypeB struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
@@ -0,0 +1,34 @@
+// Package grouppkg holds object types that reference definitions of their package and a split document.
+// +fybrik:validation:schema
+package grouppkg
+
+// +fybrik:validation:object="order"
+type Order struct {
+	Item Item `json:"item"`
+	// +fybrik:validation:object="address"
+	Address Address `json:"address"`
+}
+
+// +fybrik:validation:object="invoice"
+type Invoice struct {
+	Total Amount `json:"total"`
+}
+
+type Item struct {
+	Name  string `json:"name"`
+	Price Amount `json:"price"`
+}
+
+type Amount struct {
+	// +kubebuilder:validation:Minimum=0
+	Value    int    `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type Unused struct {
+	Note string `json:"note"`
+}
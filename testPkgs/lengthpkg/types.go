@@ -0,0 +1,11 @@
+// Package lengthpkg holds an object type that references a type with a negative length.
+// +fybrik:validation:schema
+package lengthpkg
+
+// +kubebuilder:validation:MinLength=-1
+type Name string
+
+// +fybrik:validation:object="length"
+type Lengthed struct {
+	Name Name `json:"name"`
+}
@@ -0,0 +1,8 @@
+// Package patternpkg holds a type with a field whose pattern is malformed.
+// +fybrik:validation:schema
+package patternpkg
+
+type Patterned struct {
+	// +kubebuilder:validation:Pattern="^[a-z"
+	Name string `json:"name"`
+}
@@ -0,0 +1,16 @@
+// Package remote holds an implementation of the interfaces of pluginpkg, which it doesn't import.
+// +fybrik:validation:schema
+package remote
+
+type Remote struct {
+	// +kubebuilder:validation:Pattern="^https://"
+	URL string `json:"url"`
+}
+
+func (r Remote) Endpoint() string {
+	return r.URL
+}
+
+func (r Remote) String() string {
+	return r.URL
+}
@@ -0,0 +1,29 @@
+// Package pluginpkg holds interface types whose implementations are listed by oneOf markers, including a type of another package.
+// +fybrik:validation:schema
+package pluginpkg
+
+import "fmt"
+
+// +fybrik:validation:oneOf={Local,"fybrik.io/json-schema-generator/testPkgs/pluginpkg/remote.Remote"}
+type Plugin interface {
+	Endpoint() string
+}
+
+type Local struct {
+	Path string `json:"path"`
+}
+
+func (l Local) Endpoint() string {
+	return "file://" + l.Path
+}
+
+func (l *Local) String() string {
+	return l.Path
+}
+
+type Config struct {
+	Plugin Plugin `json:"plugin"`
+	// Target is one of the implementations listed by the marker of the field, since fmt.Stringer has no marker
+	// +fybrik:validation:oneOf={Local,"fybrik.io/json-schema-generator/testPkgs/pluginpkg/remote.Remote"}
+	Target fmt.Stringer `json:"target"`
+}
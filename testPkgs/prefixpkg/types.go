@@ -0,0 +1,12 @@
+// Package prefixpkg holds types with markers of a custom prefix.
+// +acme:validation:schema
+package prefixpkg
+
+// +acme:validation:object="acme_widget"
+type Widget struct {
+	// +acme:validation:title=Color
+	Color Color `json:"color"`
+}
+
+// +acme:validation:enum=["red", "green"]
+type Color string
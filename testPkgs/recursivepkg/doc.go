@@ -0,0 +1,5 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package recursivepkg
@@ -0,0 +1,12 @@
+package recursivepkg
+
+type Node struct {
+	// +kubebuilder:validation:MinLength=1
+	Name     string  `json:"name"`
+	Leaf     *Leaf   `json:"leaf,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+type Leaf struct {
+	Value int `json:"value"`
+}
@@ -8,3 +8,7 @@ type SchemaType1 struct {
 	// +kubebuilder:validation:Required
 	SchemaF2 string `json:"schemaf2,omitempty"`
 }
+
+type UnusedType struct {
+	UnusedF1 string `json:"unusedf1,omitempty"`
+}
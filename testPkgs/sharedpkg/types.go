@@ -0,0 +1,17 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sharedpkg holds sample types with the sharedDef marker.
+// +fybrik:validation:schema
+package sharedpkg
+
+// +fybrik:validation:sharedDef
+type Labels map[string]LabelValue
+
+// +kubebuilder:validation:MaxLength=8
+type LabelValue string
+
+type Owner struct {
+	Name   string `json:"name"`
+	Labels Labels `json:"labels"`
+}
@@ -0,0 +1,10 @@
+// Package sqlpkg holds types with fields of the nullable types of database/sql.
+// +fybrik:validation:schema
+package sqlpkg
+
+import "database/sql"
+
+type Record struct {
+	Name  sql.NullString `json:"name"`
+	Count sql.NullInt64  `json:"count"`
+}
@@ -0,0 +1,6 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testfilepkg holds sample types declared in regular and in test files.
+// +fybrik:validation:schema
+package testfilepkg
@@ -0,0 +1,5 @@
+package testfilepkg
+
+type RegularType struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,6 @@
+package testfilepkg
+
+type FixtureType struct {
+	Regular RegularType `json:"regular"`
+	Count   int         `json:"count"`
+}
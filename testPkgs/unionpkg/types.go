@@ -0,0 +1,28 @@
+// Package unionpkg holds interface types with invalid oneOf markers.
+// +fybrik:validation:schema
+package unionpkg
+
+// +fybrik:validation:oneOf={Circle,Missing,Label}
+type Shape interface {
+	Area() float64
+}
+
+// Plain has no oneOf marker, so its values can't be described
+type Plain interface {
+	Name() string
+}
+
+type Circle struct {
+	Radius int `json:"radius"`
+}
+
+func (c Circle) Area() float64 {
+	return float64(3 * c.Radius * c.Radius)
+}
+
+type Label string
+
+type Drawing struct {
+	Shapes map[string]Shape `json:"shapes"`
+	Plain  Plain            `json:"plain"`
+}
@@ -0,0 +1,15 @@
+package validationpkg
+
+type StringMap = map[string]string
+
+type LevelList = []Level
+
+// BoxMap is an alias of a map of an instantiated generic type
+type BoxMap = map[string]Wrapper[int]
+
+type Aliased struct {
+	Labels StringMap `json:"labels"`
+	Levels LevelList `json:"levels"`
+	Box    IntBox    `json:"box,omitempty"`
+	Boxes  BoxMap    `json:"boxes,omitempty"`
+}
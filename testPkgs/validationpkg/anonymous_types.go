@@ -0,0 +1,25 @@
+package validationpkg
+
+type Endpoints struct {
+	// The primary endpoint
+	Primary struct {
+		// The name of the host
+		Host string `json:"host"`
+
+		// +kubebuilder:validation:Minimum=1
+		Port int `json:"port"`
+	} `json:"primary"`
+
+	// The fallback endpoint
+	Fallback *struct {
+		// The name of the host
+		Host string `json:"host"`
+
+		// +kubebuilder:validation:Minimum=1
+		Port int `json:"port"`
+	} `json:"fallback,omitempty"`
+
+	Options struct {
+		Verbose bool `json:"verbose,omitempty"`
+	} `json:"options"`
+}
@@ -0,0 +1,15 @@
+package validationpkg
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+type Amounts struct {
+	Total big.Int    `json:"total"`
+	Ratio *big.Float `json:"ratio"`
+}
+
+type Measurement struct {
+	Value json.Number `json:"value"`
+}
@@ -0,0 +1,10 @@
+package validationpkg
+
+// +fybrik:validation:exactlyOneOf={"access_key","secret_ref"}
+type StoreAccess struct {
+	AccessKey  string `json:"access_key"`
+	SecretRef  string `json:"secret_ref,omitempty"`
+	MaxRetries int    `json:"max_retries"`
+	RetryDelay int    `json:"retryDelay,omitempty"`
+	Region     string `json:"region"`
+}
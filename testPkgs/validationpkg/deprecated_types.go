@@ -0,0 +1,20 @@
+package validationpkg
+
+type Relocated struct {
+	// Endpoint is the address of the service.
+	//
+	// Deprecated: use Address instead.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Address is the address of the service. Deprecated: is not a paragraph in this sentence.
+	Address string `json:"address,omitempty"`
+
+	Settings *LegacySettings `json:"settings,omitempty"`
+}
+
+// LegacySettings holds the settings of the first version.
+//
+// Deprecated: use Relocated instead.
+type LegacySettings struct {
+	Verbose bool `json:"verbose,omitempty"`
+}
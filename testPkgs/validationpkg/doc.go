@@ -0,0 +1,6 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package validationpkg holds sample types exercising the supported validation markers.
+// +fybrik:validation:schema
+package validationpkg
@@ -0,0 +1,9 @@
+package validationpkg
+
+type Marker struct{}
+
+type Flags struct {
+	Marker Marker              `json:"marker"`
+	Anon   struct{}            `json:"anon"`
+	Set    map[string]struct{} `json:"set"`
+}
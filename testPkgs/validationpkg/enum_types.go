@@ -0,0 +1,48 @@
+package validationpkg
+
+// +kubebuilder:validation:Enum=1;2;3
+type Level int
+
+type Leveled struct {
+	Level Level `json:"level"`
+}
+
+// +fybrik:validation:enum=["a;b","c,d"]
+type Separated string
+
+type SeparatedEnums struct {
+	Separated Separated `json:"separated"`
+
+	// +fybrik:validation:enum=[10, 20]
+	Number int `json:"number"`
+}
+
+type Priority int
+
+const (
+	// Low priority requests are served last
+	Low Priority = iota
+	// Medium priority requests are served
+	// before low priority ones
+	Medium
+	High // High priority requests are served first
+)
+
+type Prioritized struct {
+	// The priority of the request
+	Priority Priority `json:"priority"`
+}
+
+// +kubebuilder:validation:Enum=true
+type Flag bool
+
+// +fybrik:validation:enum=[true]
+type Accepted bool
+
+type Flagged struct {
+	Flag     Flag     `json:"flag"`
+	Accepted Accepted `json:"accepted"`
+
+	// +kubebuilder:validation:Enum=false
+	Disabled bool `json:"disabled"`
+}
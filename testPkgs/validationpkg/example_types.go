@@ -0,0 +1,11 @@
+package validationpkg
+
+type Exemplified struct {
+	Name     string   `json:"name" example:"foo"`
+	Replicas int      `json:"replicas" example:"3"`
+	Zones    []string `json:"zones" example:"[\"eu\", \"us\"]"`
+	Comment  string   `json:"comment"`
+
+	// +kubebuilder:default=1
+	Retries int `json:"retries,omitempty" example:"5"`
+}
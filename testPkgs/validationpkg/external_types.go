@@ -0,0 +1,7 @@
+package validationpkg
+
+import "fybrik.io/json-schema-generator/testPkgs/externalpkg"
+
+type ExternalRef struct {
+	Field externalpkg.ExternalType `json:"field"`
+}
@@ -0,0 +1,8 @@
+package validationpkg
+
+// +fybrik:validation:extraValues={"type": "string", "maxLength": 8}
+type Extensible struct {
+	Name string `json:"name"`
+
+	Size int `json:"size,omitempty"`
+}
@@ -0,0 +1,13 @@
+package validationpkg
+
+// +kubebuilder:validation:Minimum=0
+// +kubebuilder:validation:Maximum=1
+type Ratio float64
+
+type FloatBounds struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	Probability float64 `json:"probability"`
+
+	Ratio Ratio `json:"ratio"`
+}
@@ -0,0 +1,6 @@
+package validationpkg
+
+type Contact struct {
+	// +kubebuilder:validation:Format=email
+	Email string `json:"email"`
+}
@@ -0,0 +1,9 @@
+package validationpkg
+
+type FreeForm struct {
+	Value    any                    `json:"value"`
+	Legacy   interface{}            `json:"legacy,omitempty"`
+	Items    []any                  `json:"items,omitempty"`
+	Settings map[string]any         `json:"settings,omitempty"`
+	Nested   map[string]interface{} `json:"nested,omitempty"`
+}
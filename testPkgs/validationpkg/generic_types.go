@@ -0,0 +1,26 @@
+package validationpkg
+
+// Wrapper holds a value of any type
+type Wrapper[T any] struct {
+	// +kubebuilder:validation:Optional
+	Value T `json:"value"`
+
+	// +kubebuilder:validation:MinLength=1
+	Label string `json:"label,omitempty"`
+}
+
+// Pair holds two values of possibly different types
+type Pair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type IntBox = Wrapper[int]
+
+type Boxes struct {
+	IntBox   Wrapper[int]        `json:"intBox"`
+	PointBox Wrapper[Point]      `json:"pointBox"`
+	Aliased  IntBox              `json:"aliased"`
+	Points   []*Wrapper[Point]   `json:"points,omitempty"`
+	Entry    Pair[string, Point] `json:"entry"`
+}
@@ -0,0 +1,36 @@
+package validationpkg
+
+type Base struct {
+	Name string `json:"name"`
+}
+
+type Derived struct {
+	Base `json:",inline"`
+	Size int `json:"size"`
+}
+
+type PointerDerived struct {
+	*Base `json:",inline"`
+	Size  int `json:"size"`
+}
+
+type Labeled struct {
+	Label string `json:"label"`
+}
+
+type Versioned struct {
+	Version int `json:"version"`
+}
+
+type MultiDerived struct {
+	Versioned `json:",inline"`
+	Base      `json:",inline"`
+	Labeled   `json:",inline"`
+	Size      int `json:"size"`
+}
+
+type RenamedBase Base
+
+type Renaming struct {
+	Renamed RenamedBase `json:"renamed"`
+}
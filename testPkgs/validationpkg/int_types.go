@@ -0,0 +1,9 @@
+package validationpkg
+
+type Counters struct {
+	Count int `json:"count"`
+
+	Total uint `json:"total"`
+
+	Small int32 `json:"small"`
+}
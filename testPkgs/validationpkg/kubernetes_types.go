@@ -0,0 +1,23 @@
+package validationpkg
+
+type ListItem struct {
+	Name string `json:"name"`
+}
+
+type KubernetesExtended struct {
+	// +kubebuilder:validation:XIntOrString
+	Port string `json:"port"`
+
+	// +listType=map
+	// +listMapKey=name
+	Items []ListItem `json:"items"`
+
+	// +mapType=atomic
+	Labels map[string]string `json:"labels"`
+
+	// +kubebuilder:validation:EmbeddedResource
+	Resource map[string]string `json:"resource"`
+
+	// +kubebuilder:validation:XValidation:rule="self.size() > 0"
+	Name string `json:"name"`
+}
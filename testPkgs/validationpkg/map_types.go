@@ -0,0 +1,37 @@
+package validationpkg
+
+type KeyValidation struct {
+	// +fybrik:validation:key:MaxLength=5
+	// +fybrik:validation:key:Pattern=`^[a-z]+$`
+	Labels map[string]string `json:"labels"`
+
+	// +fybrik:validation:key:Enum=red;green
+	Colors map[string]int `json:"colors"`
+}
+
+type PatternKeys struct {
+	// +fybrik:validation:key:Pattern=`^x-[a-z]+$`
+	Extensions map[string]int `json:"extensions"`
+}
+
+type PatternValues struct {
+	// +fybrik:validation:patternProperty={"pattern": "^x-", "schema": {"type": "string", "maxLength": 3}}
+	// +fybrik:validation:patternProperty={"pattern": "^n-", "schema": {"type": "string", "pattern": "^[0-9]+$"}}
+	Annotations map[string]string `json:"annotations"`
+}
+
+// +kubebuilder:validation:MaxProperties=2
+type Annotations map[string]string
+
+type Blobs struct {
+	Files map[string][]byte `json:"files"`
+}
+
+// +kubebuilder:validation:MinProperties=1
+type Config map[string]string
+
+type Configured struct {
+	Config Config `json:"config"`
+
+	Overrides map[string]Config `json:"overrides,omitempty"`
+}
@@ -0,0 +1,7 @@
+package validationpkg
+
+import "fybrik.io/json-schema-generator/testPkgs/externalpkg"
+
+type NestedMaps struct {
+	Tags map[string]map[string]externalpkg.Tag `json:"tags"`
+}
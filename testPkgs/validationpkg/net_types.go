@@ -0,0 +1,8 @@
+package validationpkg
+
+import "net"
+
+type Network struct {
+	Address net.IP    `json:"address"`
+	Subnet  net.IPNet `json:"subnet"`
+}
@@ -0,0 +1,8 @@
+package validationpkg
+
+type PointerFields struct {
+	Name   *string `json:"name"`
+	Point  *Point  `json:"point"`
+	Counts *[]int  `json:"counts"`
+	Plain  string  `json:"plain"`
+}
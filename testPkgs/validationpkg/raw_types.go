@@ -0,0 +1,7 @@
+package validationpkg
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+type Embedding struct {
+	Embedded runtime.RawExtension `json:"embedded"`
+}
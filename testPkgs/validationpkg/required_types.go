@@ -0,0 +1,8 @@
+package validationpkg
+
+type RequiredOmitEmpty struct {
+	// +kubebuilder:validation:Required
+	Required string `json:"required,omitempty"`
+
+	Optional string `json:"optional,omitempty"`
+}
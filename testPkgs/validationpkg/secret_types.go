@@ -0,0 +1,10 @@
+package validationpkg
+
+type Credentials struct {
+	User     string `json:"user" example:"admin"`
+	Password string `json:"password" secret:"true" example:"hunter2"`
+	// +fybrik:validation:secret
+	Token string `json:"token"`
+	// +fybrik:validation:secret
+	PIN int `json:"pin"`
+}
@@ -0,0 +1,15 @@
+package validationpkg
+
+import "fybrik.io/json-schema-generator/testPkgs/externalpkg"
+
+type Shaped struct {
+	// +fybrik:validation:shape={"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+	externalpkg.Named `json:",inline"`
+
+	Size int `json:"size"`
+}
+
+type DialectShaped struct {
+	// +fybrik:validation:shape={"$schema": "http://json-schema.org/draft-04/schema#", "type": "object"}
+	Metadata interface{} `json:"metadata"`
+}
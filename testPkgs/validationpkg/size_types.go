@@ -0,0 +1,14 @@
+package validationpkg
+
+// +fybrik:validation:maxBytes=4096
+type Payload struct {
+	Data []byte `json:"data"`
+}
+
+// +fybrik:validation:maxBytes=64
+type Token string
+
+type Upload struct {
+	Payload Payload `json:"payload"`
+	Token   Token   `json:"token"`
+}
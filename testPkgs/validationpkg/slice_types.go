@@ -0,0 +1,40 @@
+package validationpkg
+
+// +kubebuilder:validation:MinItems=1
+// +kubebuilder:validation:MaxItems=3
+type Tags []string
+
+type Tagged struct {
+	Tags Tags `json:"tags"`
+}
+
+type Point struct {
+	Coordinates [3]int `json:"coordinates"`
+}
+
+type PointerItems struct {
+	Points []*Point          `json:"points"`
+	ByName map[string]*Point `json:"byName"`
+}
+
+type PointerMap = map[string]*Point
+
+type AliasedPointerItems struct {
+	ByName PointerMap `json:"byName"`
+
+	Boxed Wrapper[[]*Point] `json:"boxed,omitempty"`
+}
+
+type OptionalCollections struct {
+	// The names of the items
+	Names    []string          `json:"names"`
+	Aliases  []string          `json:"aliases,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Tags     Tags              `json:"tags,omitempty"`
+	Override *[]string         `json:"override,omitempty"`
+}
+
+type PointerSlice struct {
+	Points   *[]Point `json:"points"`
+	Optional *[]Point `json:"optional,omitempty"`
+}
@@ -0,0 +1,11 @@
+package validationpkg
+
+type SplitPart struct {
+	Name  string `json:"name"`
+	Level Level  `json:"level"`
+}
+
+type Composite struct {
+	// +fybrik:validation:object="split_part"
+	Part SplitPart `json:"part"`
+}
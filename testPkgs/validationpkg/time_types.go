@@ -0,0 +1,20 @@
+package validationpkg
+
+import "time"
+
+type TimeFormats struct {
+	DateTime time.Time `json:"dateTime"`
+
+	// +fybrik:validation:timeFormat=date
+	Date time.Time `json:"date"`
+
+	// +fybrik:validation:timeFormat=unix
+	Unix *time.Time `json:"unix"`
+}
+
+type Schedule struct {
+	Start *time.Time `json:"start"`
+
+	// +fybrik:validation:timeFormat=date
+	End *time.Time `json:"end,omitempty"`
+}
@@ -0,0 +1,9 @@
+package validationpkg
+
+type Titled struct {
+	// The name of the dataset
+	// +fybrik:validation:title="Dataset name"
+	Name string `json:"name"`
+
+	Description string `json:"description"`
+}
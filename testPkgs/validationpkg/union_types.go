@@ -0,0 +1,35 @@
+package validationpkg
+
+// +fybrik:validation:exactlyOneOf={"file","url","inline"}
+type Source struct {
+	File   string `json:"file"`
+	URL    string `json:"url,omitempty"`
+	Inline string `json:"inline,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// +fybrik:validation:oneOf={Circle,Square}
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	// +kubebuilder:validation:Minimum=0
+	Radius int `json:"radius"`
+}
+
+func (c Circle) Area() float64 {
+	return float64(3 * c.Radius * c.Radius)
+}
+
+type Square struct {
+	Side int `json:"side"`
+}
+
+func (s *Square) Area() float64 {
+	return float64(s.Side * s.Side)
+}
+
+type Drawing struct {
+	Shapes map[string]Shape `json:"shapes"`
+}
@@ -0,0 +1,15 @@
+package validationpkg
+
+import (
+	"github.com/google/uuid"
+
+	"fybrik.io/json-schema-generator/testPkgs/externalpkg"
+)
+
+type Identified struct {
+	UID uuid.UUID `json:"uid"`
+}
+
+type CustomIdentified struct {
+	ID externalpkg.ID `json:"id"`
+}
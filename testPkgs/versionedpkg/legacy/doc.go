@@ -0,0 +1,8 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package legacy holds sample types of an API version set by the versionName marker.
+// +fybrik:validation:schema
+// +groupName=versioned.fybrik.io
+// +versionName=v1alpha1
+package legacy
@@ -0,0 +1,6 @@
+package legacy
+
+type WidgetSpec struct {
+	// +kubebuilder:validation:Minimum=1
+	Count int `json:"count"`
+}
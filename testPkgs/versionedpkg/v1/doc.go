@@ -0,0 +1,7 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package v1 holds sample types of a package named after an API version, without the versionName marker.
+// +fybrik:validation:schema
+// +groupName=versioned.fybrik.io
+package v1
@@ -0,0 +1,13 @@
+package v1
+
+import "fybrik.io/json-schema-generator/testPkgs/versionedpkg/legacy"
+
+// +fybrik:validation:object="widget"
+type Widget struct {
+	Spec WidgetSpec `json:"spec"`
+}
+
+type WidgetSpec struct {
+	Size   int               `json:"size"`
+	Legacy legacy.WidgetSpec `json:"legacy"`
+}